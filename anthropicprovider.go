@@ -0,0 +1,216 @@
+package goreact
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	// DefaultAnthropicURL is the address of the Anthropic API.
+	DefaultAnthropicURL = "https://api.anthropic.com"
+	// DefaultAnthropicModel is the model used unless configured
+	// otherwise with WithModel.
+	DefaultAnthropicModel = "claude-3-5-sonnet-latest"
+
+	anthropicVersion = "2023-06-01"
+)
+
+// AnthropicProvider sends requests to the Anthropic Messages API.
+type AnthropicProvider struct {
+	client      *http.Client
+	apiKey      string
+	baseURL     string
+	model       string
+	maxTokens   int
+	temperature float64
+	stop        []string
+}
+
+func NewAnthropicProvider(apiKey string) (*AnthropicProvider, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("api key cannot be empty")
+	}
+	return &AnthropicProvider{
+		client:      http.DefaultClient,
+		apiKey:      apiKey,
+		baseURL:     DefaultAnthropicURL,
+		model:       DefaultAnthropicModel,
+		maxTokens:   4096,
+		temperature: 0.1,
+	}, nil
+}
+
+func (a *AnthropicProvider) WithModel(model string) *AnthropicProvider {
+	a.model = model
+	return a
+}
+
+func (a *AnthropicProvider) Model() string {
+	return a.model
+}
+
+func (a *AnthropicProvider) WithBaseURL(baseURL string) *AnthropicProvider {
+	a.baseURL = strings.TrimSuffix(baseURL, "/")
+	return a
+}
+
+// WithMaxTokens sets the maximum amount of tokens of each response
+// (default 4096). The Messages API requires this limit.
+func (a *AnthropicProvider) WithMaxTokens(maxTokens int) *AnthropicProvider {
+	a.maxTokens = maxTokens
+	return a
+}
+
+func (a *AnthropicProvider) WithTemperature(temperature float64) *AnthropicProvider {
+	a.temperature = temperature
+	return a
+}
+
+// WithStop replaces the stop sequences. By default the generation
+// stops at the stop sequences of the protocol of the request, see
+// ProtocolFromContext.
+func (a *AnthropicProvider) WithStop(stop ...string) *AnthropicProvider {
+	a.stop = append([]string{}, stop...)
+	return a
+}
+
+func (a *AnthropicProvider) WithHTTPClient(client *http.Client) *AnthropicProvider {
+	a.client = client
+	return a
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicRequest struct {
+	Model         string             `json:"model"`
+	MaxTokens     int                `json:"max_tokens"`
+	System        string             `json:"system,omitempty"`
+	Messages      []anthropicMessage `json:"messages"`
+	StopSequences []string           `json:"stop_sequences,omitempty"`
+	Temperature   float64            `json:"temperature"`
+}
+
+type anthropicResponse struct {
+	Model   string `json:"model"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+type anthropicError struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (a *AnthropicProvider) Request(ctx context.Context, system, prompt string) (string, error) {
+	return a.Chat(ctx, []Message{
+		{Role: RoleSystem, Content: system},
+		{Role: RoleUser, Content: prompt},
+	})
+}
+
+// Chat sends the conversation to the Messages API. System messages
+// are sent in the separate system field and consecutive messages of
+// the same role are merged as the API requires alternating roles.
+func (a *AnthropicProvider) Chat(ctx context.Context, messages []Message) (string, error) {
+	req := anthropicRequest{
+		Model:         a.model,
+		MaxTokens:     a.maxTokens,
+		StopSequences: stopSequences(ctx, a.stop),
+		Temperature:   a.temperature,
+	}
+	var system []string
+	for _, message := range messages {
+		role := message.Role
+		switch role {
+		case RoleSystem:
+			system = append(system, message.Content)
+			continue
+		case RoleTool:
+			role = RoleUser
+		}
+		if n := len(req.Messages); n > 0 && req.Messages[n-1].Role == role {
+			req.Messages[n-1].Content += "\n" + message.Content
+			continue
+		}
+		req.Messages = append(req.Messages, anthropicMessage{
+			Role:    role,
+			Content: message.Content,
+		})
+	}
+	req.System = strings.Join(system, "\n")
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		a.baseURL+"/v1/messages", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", a.apiKey)
+	httpReq.Header.Set("anthropic-version", anthropicVersion)
+
+	httpResp, err := a.client.Do(httpReq)
+	if err != nil {
+		return "", err
+	}
+	defer httpResp.Body.Close()
+
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return "", newAnthropicError(httpResp, data)
+	}
+	var resp anthropicResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	ReportUsage(ctx, Usage{
+		Model:            resp.Model,
+		PromptTokens:     resp.Usage.InputTokens,
+		CompletionTokens: resp.Usage.OutputTokens,
+	})
+	var text []string
+	for _, content := range resp.Content {
+		if content.Type == "text" {
+			text = append(text, content.Text)
+		}
+	}
+	return strings.Join(text, ""), nil
+}
+
+func newAnthropicError(resp *http.Response, data []byte) error {
+	providerErr := &ProviderError{
+		Provider:   "anthropic",
+		StatusCode: resp.StatusCode,
+		Message:    strings.TrimSpace(string(data)),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+	var apiErr anthropicError
+	if err := json.Unmarshal(data, &apiErr); err == nil && apiErr.Error.Type != "" {
+		providerErr.Type = apiErr.Error.Type
+		providerErr.Message = apiErr.Error.Message
+	}
+	return providerErr
+}
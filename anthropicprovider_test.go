@@ -0,0 +1,122 @@
+package goreact
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+func newAnthropicServer(t *testing.T, handler http.HandlerFunc) *AnthropicProvider {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	provider, err := NewAnthropicProvider("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider.WithBaseURL(server.URL)
+}
+
+func TestAnthropicProviderChatRequest(t *testing.T) {
+	var got anthropicRequest
+	provider := newAnthropicServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if key := r.Header.Get("x-api-key"); key != "secret" {
+			t.Errorf("unexpected x-api-key %q", key)
+		}
+		if version := r.Header.Get("anthropic-version"); version != anthropicVersion {
+			t.Errorf("unexpected anthropic-version %q", version)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		w.Write([]byte(`{"model":"claude-3-5-sonnet-20241022","content":[{"type":"text","text":"THOUGHT: look\n"},{"type":"text","text":"ACTION: look north"}],"stop_reason":"stop_sequence","usage":{"input_tokens":12,"output_tokens":5}}`))
+	})
+
+	ctx, collector := withUsageCollector(context.Background())
+	response, err := provider.Chat(ctx, []Message{
+		{Role: RoleSystem, Content: "system"},
+		{Role: RoleUser, Content: "QUESTION: coins?"},
+		{Role: RoleUser, Content: "more context"},
+		{Role: RoleAssistant, Content: "ACTION: look"},
+		{Role: RoleTool, Content: "OBSERVATION: a coin"},
+		{Role: RoleSystem, Content: "second system"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response != "THOUGHT: look\nACTION: look north" {
+		t.Errorf("unexpected response %q", response)
+	}
+	if got.System != "system\nsecond system" {
+		t.Errorf("unexpected system %q", got.System)
+	}
+	want := []anthropicMessage{
+		{Role: RoleUser, Content: "QUESTION: coins?\nmore context"},
+		{Role: RoleAssistant, Content: "ACTION: look"},
+		{Role: RoleUser, Content: "OBSERVATION: a coin"},
+	}
+	if !slices.Equal(got.Messages, want) {
+		t.Errorf("expected messages %v, got %v", want, got.Messages)
+	}
+	if !slices.Equal(got.StopSequences, DefaultProtocol.StopSequences()) {
+		t.Errorf("unexpected stop sequences %v", got.StopSequences)
+	}
+	if got.Model != DefaultAnthropicModel || got.MaxTokens != 4096 {
+		t.Errorf("unexpected model %q or max tokens %d", got.Model, got.MaxTokens)
+	}
+	usage, _ := collector.collected()
+	if usage != (Usage{Model: "claude-3-5-sonnet-20241022", PromptTokens: 12, CompletionTokens: 5}) {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+}
+
+func TestAnthropicProviderOverloaded(t *testing.T) {
+	provider := newAnthropicServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3")
+		w.WriteHeader(529)
+		w.Write([]byte(`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`))
+	})
+
+	_, err := provider.Request(context.Background(), "system", "prompt")
+	if !errors.Is(err, ErrOverloaded) {
+		t.Fatalf("expected ErrOverloaded, got %v", err)
+	}
+	if class := ClassifyError(err); class != ErrorOverload {
+		t.Errorf("expected class overload, got %v", class)
+	}
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) {
+		t.Fatalf("expected a *ProviderError, got %T", err)
+	}
+	if providerErr.Type != "overloaded_error" || providerErr.Message != "Overloaded" ||
+		providerErr.StatusCode != 529 {
+		t.Errorf("unexpected error %+v", providerErr)
+	}
+	if providerErr.RetryAfter != 3*time.Second {
+		t.Errorf("unexpected retry after %v", providerErr.RetryAfter)
+	}
+}
+
+func TestAnthropicProviderRateLimited(t *testing.T) {
+	provider := newAnthropicServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte("slow down"))
+	})
+
+	_, err := provider.Request(context.Background(), "system", "prompt")
+	if !errors.Is(err, ErrRateLimited) || ClassifyError(err) != ErrorRateLimit {
+		t.Fatalf("expected a rate limit error, got %v", err)
+	}
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) && providerErr.Message != "slow down" {
+		t.Errorf("expected the body as message, got %q", providerErr.Message)
+	}
+}
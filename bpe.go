@@ -0,0 +1,397 @@
+package goreact
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Encoding selects the pre-tokenization rules of a BPE vocabulary.
+type Encoding string
+
+const (
+	// EncodingCl100k is used by GPT-4, GPT-3.5 and the text-embedding-3
+	// models.
+	EncodingCl100k Encoding = "cl100k_base"
+	// EncodingO200k is used by GPT-4o and the o-series models.
+	EncodingO200k Encoding = "o200k_base"
+)
+
+// EncodingForModel returns the encoding of an OpenAI model.
+func EncodingForModel(model string) (Encoding, bool) {
+	for _, prefix := range []string{"gpt-4o", "gpt-4.1", "gpt-4.5", "gpt-5", "chatgpt-4o", "o1", "o3", "o4"} {
+		if strings.HasPrefix(model, prefix) {
+			return EncodingO200k, true
+		}
+	}
+	for _, prefix := range []string{"gpt-4", "gpt-3.5", "gpt-35", "text-embedding-3", "text-embedding-ada-002"} {
+		if strings.HasPrefix(model, prefix) {
+			return EncodingCl100k, true
+		}
+	}
+	return "", false
+}
+
+// BPETokenizer is a byte pair encoding tokenizer which is compatible
+// with the cl100k_base and o200k_base encodings of OpenAI.
+//
+// The vocabularies are not embedded into this package. They have 1.7 MB
+// and 3.6 MB and would be linked into every program importing goreact,
+// even when it talks to a model with another tokenizer. Importing the
+// package github.com/dgruber/goreact/tiktoken embeds and registers them,
+// otherwise they are read from the .tiktoken files, see
+// LoadBPETokenizerForModel.
+type BPETokenizer struct {
+	encoding Encoding
+	ranks    map[string]int
+	tokens   map[int]string
+}
+
+// NewBPETokenizer reads a vocabulary in the format of the .tiktoken
+// files published by OpenAI: one base64 encoded token and its rank per
+// line.
+func NewBPETokenizer(encoding Encoding, vocabulary io.Reader) (*BPETokenizer, error) {
+	if encoding != EncodingCl100k && encoding != EncodingO200k {
+		return nil, fmt.Errorf("unknown encoding %q", encoding)
+	}
+	t := &BPETokenizer{
+		encoding: encoding,
+		ranks:    make(map[string]int),
+		tokens:   make(map[int]string),
+	}
+	scanner := bufio.NewScanner(vocabulary)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid vocabulary in line %d", line)
+		}
+		token, err := base64.StdEncoding.DecodeString(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid token in line %d: %w", line, err)
+		}
+		rank, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid rank in line %d: %w", line, err)
+		}
+		t.ranks[string(token)] = rank
+		t.tokens[rank] = string(token)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read vocabulary: %w", err)
+	}
+	return t, nil
+}
+
+// LoadBPETokenizer reads the vocabulary from a .tiktoken file, like
+// https://openaipublic.blob.core.windows.net/encodings/o200k_base.tiktoken
+func LoadBPETokenizer(encoding Encoding, path string) (*BPETokenizer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewBPETokenizer(encoding, bytes.NewReader(data))
+}
+
+// LoadBPETokenizerForModel reads the vocabulary of the encoding of an
+// OpenAI model from dir, which contains the .tiktoken files with their
+// original names like cl100k_base.tiktoken.
+func LoadBPETokenizerForModel(model, dir string) (*BPETokenizer, error) {
+	encoding, ok := EncodingForModel(model)
+	if !ok {
+		return nil, fmt.Errorf("no known encoding for model %q", model)
+	}
+	return LoadBPETokenizer(encoding, filepath.Join(dir, string(encoding)+".tiktoken"))
+}
+
+var (
+	vocabulariesMu sync.Mutex
+	vocabularies   = make(map[Encoding]*vocabulary)
+)
+
+// vocabulary is a registered vocabulary which is read on first use.
+type vocabulary struct {
+	open      func() (io.Reader, error)
+	once      sync.Once
+	tokenizer *BPETokenizer
+	err       error
+}
+
+// RegisterBPEVocabulary makes the vocabulary of an encoding available
+// to BPETokenizerForModel and hence to NewReact. open returns the
+// content of the .tiktoken file, it is called once when the vocabulary
+// is used the first time. It is called by the package
+// github.com/dgruber/goreact/tiktoken which embeds the vocabularies.
+func RegisterBPEVocabulary(encoding Encoding, open func() (io.Reader, error)) {
+	vocabulariesMu.Lock()
+	defer vocabulariesMu.Unlock()
+	vocabularies[encoding] = &vocabulary{open: open}
+}
+
+// ErrNoVocabulary is returned by BPETokenizerForModel when the model
+// has no known encoding or its vocabulary was not registered.
+var ErrNoVocabulary = errors.New("no BPE vocabulary")
+
+// BPETokenizerForModel returns the tokenizer of an OpenAI model from
+// the registered vocabularies. All callers share the tokenizer of an
+// encoding.
+func BPETokenizerForModel(model string) (*BPETokenizer, error) {
+	encoding, ok := EncodingForModel(model)
+	if !ok {
+		return nil, fmt.Errorf("%w: no known encoding for model %q", ErrNoVocabulary, model)
+	}
+	vocabulariesMu.Lock()
+	v, ok := vocabularies[encoding]
+	vocabulariesMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not registered", ErrNoVocabulary, encoding)
+	}
+	v.once.Do(func() {
+		var vocabulary io.Reader
+		if vocabulary, v.err = v.open(); v.err != nil {
+			return
+		}
+		if closer, ok := vocabulary.(io.Closer); ok {
+			defer closer.Close()
+		}
+		v.tokenizer, v.err = NewBPETokenizer(encoding, vocabulary)
+	})
+	if v.err != nil {
+		return nil, fmt.Errorf("failed to read the vocabulary %s: %w", encoding, v.err)
+	}
+	return v.tokenizer, nil
+}
+
+// Encode returns the token ids of the text. Special tokens like
+// <|endoftext|> are encoded as ordinary text.
+func (t *BPETokenizer) Encode(text string) []int {
+	var ids []int
+	for _, piece := range t.split(text) {
+		if rank, ok := t.ranks[piece]; ok {
+			ids = append(ids, rank)
+			continue
+		}
+		for _, token := range t.merge(piece) {
+			ids = append(ids, t.ranks[token])
+		}
+	}
+	return ids
+}
+
+// Decode returns the text of the token ids.
+func (t *BPETokenizer) Decode(ids []int) string {
+	var text strings.Builder
+	for _, id := range ids {
+		text.WriteString(t.tokens[id])
+	}
+	return text.String()
+}
+
+func (t *BPETokenizer) Tokens(text string) []string {
+	var tokens []string
+	for _, piece := range t.split(text) {
+		if _, ok := t.ranks[piece]; ok {
+			tokens = append(tokens, piece)
+			continue
+		}
+		tokens = append(tokens, t.merge(piece)...)
+	}
+	return tokens
+}
+
+// merge applies the byte pair merges to a piece of text, always merging
+// the adjacent pair with the lowest rank first.
+func (t *BPETokenizer) merge(piece string) []string {
+	parts := make([]string, len(piece))
+	for i := range piece {
+		parts[i] = piece[i : i+1]
+	}
+	for len(parts) > 1 {
+		best, lowest := -1, math.MaxInt
+		for i := 0; i < len(parts)-1; i++ {
+			if rank, ok := t.ranks[parts[i]+parts[i+1]]; ok && rank < lowest {
+				best, lowest = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		parts[best] += parts[best+1]
+		parts = append(parts[:best+1], parts[best+2:]...)
+	}
+	return parts
+}
+
+// split pre-tokenizes the text with the rules of the encoding. The
+// rules are the regular expressions of tiktoken which cannot be
+// expressed with the regexp package as they use look-ahead.
+func (t *BPETokenizer) split(text string) []string {
+	runes := []rune(text)
+	var pieces []string
+	for i := 0; i < len(runes); {
+		var end int
+		if t.encoding == EncodingO200k {
+			end = matchO200k(runes, i)
+		} else {
+			end = matchCl100k(runes, i)
+		}
+		if end <= i {
+			// cannot happen as \s+ and the other rules cover all runes
+			end = i + 1
+		}
+		pieces = append(pieces, string(runes[i:end]))
+		i = end
+	}
+	return pieces
+}
+
+// matchCl100k returns the end of the piece starting at i for
+//
+//	(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}|
+//	 ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+(?!\S)|\s+
+func matchCl100k(s []rune, i int) int {
+	if end := matchContraction(s, i); end > i {
+		return end
+	}
+	for _, start := range optionalPrefix(s, i) {
+		if end := matchRun(s, start, unicode.IsLetter); end > start {
+			return end
+		}
+	}
+	if end := matchNumber(s, i); end > i {
+		return end
+	}
+	if end := matchPunctuation(s, i, isNewline); end > i {
+		return end
+	}
+	return matchWhitespace(s, i)
+}
+
+// matchO200k returns the end of the piece starting at i for
+//
+//	[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?|
+//	[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?|
+//	\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|\s+(?!\S)|\s+
+func matchO200k(s []rune, i int) int {
+	prefixes := optionalPrefix(s, i)
+	for _, start := range prefixes {
+		// upper* lower+: the upper case run gives back runes until
+		// the lower case run can start
+		upper := matchRun(s, start, isUpperO200k)
+		for k := upper; k >= start; k-- {
+			if k < len(s) && isLowerO200k(s[k]) {
+				return matchContraction(s, matchRun(s, k, isLowerO200k))
+			}
+		}
+	}
+	for _, start := range prefixes {
+		if upper := matchRun(s, start, isUpperO200k); upper > start {
+			return matchContraction(s, matchRun(s, upper, isLowerO200k))
+		}
+	}
+	if end := matchNumber(s, i); end > i {
+		return end
+	}
+	if end := matchPunctuation(s, i, func(r rune) bool { return isNewline(r) || r == '/' }); end > i {
+		return end
+	}
+	return matchWhitespace(s, i)
+}
+
+func isNewline(r rune) bool {
+	return r == '\r' || r == '\n'
+}
+
+func isUpperO200k(r rune) bool {
+	return unicode.In(r, unicode.Lu, unicode.Lt, unicode.Lm, unicode.Lo, unicode.M)
+}
+
+func isLowerO200k(r rune) bool {
+	return unicode.In(r, unicode.Ll, unicode.Lm, unicode.Lo, unicode.M)
+}
+
+// optionalPrefix returns the starts for [^\r\n\p{L}\p{N}]? in the
+// order they are tried.
+func optionalPrefix(s []rune, i int) []int {
+	if i < len(s) && !isNewline(s[i]) && !unicode.IsLetter(s[i]) && !unicode.IsNumber(s[i]) {
+		return []int{i + 1, i}
+	}
+	return []int{i}
+}
+
+func matchRun(s []rune, i int, is func(rune) bool) int {
+	for i < len(s) && is(s[i]) {
+		i++
+	}
+	return i
+}
+
+func matchContraction(s []rune, i int) int {
+	if i >= len(s) || s[i] != '\'' {
+		return i
+	}
+	for _, suffix := range []string{"s", "t", "re", "ve", "m", "ll", "d"} {
+		end := i + 1 + len(suffix)
+		if end <= len(s) && strings.EqualFold(string(s[i+1:end]), suffix) {
+			return end
+		}
+	}
+	return i
+}
+
+// matchNumber matches \p{N}{1,3}.
+func matchNumber(s []rune, i int) int {
+	end := i
+	for end < len(s) && end-i < 3 && unicode.IsNumber(s[end]) {
+		end++
+	}
+	return end
+}
+
+// matchPunctuation matches  ?[^\s\p{L}\p{N}]+ followed by the runes
+// of trailing.
+func matchPunctuation(s []rune, i int, trailing func(rune) bool) int {
+	isPunctuation := func(r rune) bool {
+		return !unicode.IsSpace(r) && !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}
+	start := i
+	if start < len(s) && s[start] == ' ' && start+1 < len(s) && isPunctuation(s[start+1]) {
+		start++
+	}
+	end := matchRun(s, start, isPunctuation)
+	if end == start {
+		return i
+	}
+	return matchRun(s, end, trailing)
+}
+
+// matchWhitespace matches \s*[\r\n]+|\s+(?!\S)|\s+.
+func matchWhitespace(s []rune, i int) int {
+	end := matchRun(s, i, unicode.IsSpace)
+	if end == i {
+		return i
+	}
+	// \s*[\r\n]+ ends after the last newline of the whitespace
+	for k := end - 1; k >= i; k-- {
+		if isNewline(s[k]) {
+			return k + 1
+		}
+	}
+	// \s+(?!\S) leaves the last whitespace for the following word
+	if end < len(s) && end-1 > i {
+		return end - 1
+	}
+	return end
+}
@@ -0,0 +1,153 @@
+package goreact
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBPETokenizerSplit(t *testing.T) {
+	tests := []struct {
+		encoding Encoding
+		text     string
+		pieces   []string
+	}{
+		{EncodingCl100k, "hello world!你好，世界！", []string{"hello", " world", "!你好", "，世界", "！"}},
+		{EncodingCl100k, "Hello  world", []string{"Hello", " ", " world"}},
+		{EncodingCl100k, "123456 apples", []string{"123", "456", " apples"}},
+		{EncodingCl100k, "I don't KNOW'S", []string{"I", " don", "'t", " KNOW", "'S"}},
+		{EncodingCl100k, "HelloWorld", []string{"HelloWorld"}},
+		{EncodingCl100k, "a\n\n  b", []string{"a", "\n\n", " ", " b"}},
+		{EncodingCl100k, "x  \n", []string{"x", "  \n"}},
+		{EncodingCl100k, "func main() {\n\treturn\n}", []string{"func", " main", "()", " {\n", "\treturn", "\n", "}"}},
+		{EncodingO200k, "I don't KNOW'S", []string{"I", " don't", " KNOW'S"}},
+		{EncodingO200k, "HelloWorld", []string{"Hello", "World"}},
+		{EncodingO200k, "path/to/file\n", []string{"path", "/to", "/file", "\n"}},
+		{EncodingO200k, "123456 apples", []string{"123", "456", " apples"}},
+	}
+	for _, test := range tests {
+		tokenizer := &BPETokenizer{encoding: test.encoding}
+		if pieces := tokenizer.split(test.text); !slices.Equal(pieces, test.pieces) {
+			t.Errorf("%s: split(%q) = %q, expected %q", test.encoding, test.text, pieces, test.pieces)
+		}
+	}
+}
+
+func TestBPETokenizerMerge(t *testing.T) {
+	var vocabulary strings.Builder
+	for rank, token := range []string{"a", "b", "c", " ", "ab", "abc", " a"} {
+		fmt.Fprintf(&vocabulary, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(token)), rank)
+	}
+	tokenizer, err := NewBPETokenizer(EncodingCl100k, strings.NewReader(vocabulary.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// "ab" has a lower rank than " a" and is merged first
+	ids := tokenizer.Encode("abc ab")
+	if !slices.Equal(ids, []int{5, 3, 4}) {
+		t.Errorf("unexpected ids %v", ids)
+	}
+	if text := tokenizer.Decode(ids); text != "abc ab" {
+		t.Errorf("unexpected text %q", text)
+	}
+	if tokens := tokenizer.Tokens("abc ab"); !slices.Equal(tokens, []string{"abc", " ", "ab"}) {
+		t.Errorf("unexpected tokens %q", tokens)
+	}
+}
+
+// TestBPETokenizerIDs pins token ids of tiktoken with the vocabularies
+// in testdata. They are small subsets of the vocabularies of OpenAI
+// with the byte tokens and the tokens of the tested words.
+func TestBPETokenizerIDs(t *testing.T) {
+	tests := []struct {
+		model string
+		text  string
+		ids   []int
+	}{
+		{"gpt-4", "hello world", []int{15339, 1917}},
+		{"gpt-4", "Hello world", []int{9906, 1917}},
+		{"gpt-4", "hello world!", []int{15339, 1917, 0}},
+		{"gpt-4", "t\n", []int{83, 198}},
+		{"gpt-4", " ", []int{220}},
+		{"gpt-4o", "hello world", []int{24912, 2375}},
+		{"gpt-4o", "hello world!", []int{24912, 2375, 0}},
+	}
+	for _, test := range tests {
+		tokenizer, err := LoadBPETokenizerForModel(test.model, "testdata")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids := tokenizer.Encode(test.text); !slices.Equal(ids, test.ids) {
+			t.Errorf("%s: Encode(%q) = %v, expected %v", test.model, test.text, ids, test.ids)
+		}
+		if text := tokenizer.Decode(test.ids); text != test.text {
+			t.Errorf("%s: Decode(%v) = %q", test.model, test.ids, text)
+		}
+	}
+}
+
+// registerTestdata registers the vocabularies in testdata until the end
+// of the test.
+func registerTestdata(t *testing.T) {
+	for _, encoding := range []Encoding{EncodingCl100k, EncodingO200k} {
+		RegisterBPEVocabulary(encoding, func() (io.Reader, error) {
+			return os.Open(filepath.Join("testdata", string(encoding)+".tiktoken"))
+		})
+	}
+	t.Cleanup(func() {
+		vocabulariesMu.Lock()
+		defer vocabulariesMu.Unlock()
+		clear(vocabularies)
+	})
+}
+
+func TestNewReactTokenizer(t *testing.T) {
+	tests := []struct {
+		model    string
+		register bool
+		bpe      bool
+	}{
+		{model: "gpt-4o", register: true, bpe: true},
+		{model: "gpt-3.5-turbo", register: true, bpe: true},
+		{model: "llama3", register: true, bpe: false},
+		{model: "gpt-4o", register: false, bpe: false},
+	}
+	for _, test := range tests {
+		t.Run(test.model, func(t *testing.T) {
+			if test.register {
+				registerTestdata(t)
+			}
+			r, err := NewReact(&modelProvider{model: test.model}, map[string]Command{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, bpe := r.tokenizer.(*BPETokenizer); bpe != test.bpe {
+				t.Errorf("expected a BPE tokenizer %v, got %T", test.bpe, r.tokenizer)
+			}
+		})
+	}
+
+	// the vocabulary is shared by all models of the encoding
+	registerTestdata(t)
+	gpt4, err := BPETokenizerForModel("gpt-4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if turbo, _ := BPETokenizerForModel("gpt-3.5-turbo"); turbo != gpt4 {
+		t.Error("expected the same tokenizer for cl100k_base")
+	}
+}
+
+func TestLoadBPETokenizerForModel(t *testing.T) {
+	if _, err := LoadBPETokenizerForModel("llama3", t.TempDir()); err == nil {
+		t.Error("expected an error for a model without encoding")
+	}
+	if _, err := LoadBPETokenizerForModel("gpt-4o", t.TempDir()); !os.IsNotExist(err) {
+		t.Errorf("expected a missing o200k_base.tiktoken, got %v", err)
+	}
+}
@@ -0,0 +1,310 @@
+package goreact
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Modeler is implemented by providers which can tell the model they
+// send requests to. The CacheProvider uses it as part of the key.
+type Modeler interface {
+	Model() string
+}
+
+// CacheProvider wraps an LLMProvider and caches its responses keyed on
+// a hash of the model, the system prompt, and the prompt (or all
+// messages of a conversation). It keeps an in-memory LRU cache and
+// optionally stores the responses on disk so that they survive
+// restarts. Tool requests are not cached.
+type CacheProvider struct {
+	provider LLMProvider
+	model    string
+	capacity int
+	ttl      time.Duration
+	dir      string
+	bypass   bool
+	logger   *slog.Logger
+	clock    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	hits    int
+	misses  int
+}
+
+type cacheEntry struct {
+	Key      string    `json:"key"`
+	Response string    `json:"response"`
+	Created  time.Time `json:"created"`
+}
+
+// NewCacheProvider caches up to capacity responses in memory (0 means
+// no limit). When the provider implements Modeler its model is part
+// of the key.
+func NewCacheProvider(provider LLMProvider, capacity int) *CacheProvider {
+	return &CacheProvider{
+		provider: provider,
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+		logger:   newDiscardLogger(),
+		clock:    time.Now,
+	}
+}
+
+// WithModel sets the model name which is part of the key. It is only
+// required when the provider does not implement Modeler.
+func (c *CacheProvider) WithModel(model string) *CacheProvider {
+	c.model = model
+	return c
+}
+
+// WithTTL sets how long responses are valid. 0 means forever.
+func (c *CacheProvider) WithTTL(ttl time.Duration) *CacheProvider {
+	c.ttl = ttl
+	return c
+}
+
+// WithDir stores the responses additionally as files in dir. Expired
+// files are removed when they are read.
+func (c *CacheProvider) WithDir(dir string) *CacheProvider {
+	c.dir = dir
+	return c
+}
+
+// WithLogger sets the logger which receives the failures of storing
+// responses on disk. They do not fail the request. By default nothing
+// is logged.
+func (c *CacheProvider) WithLogger(logger *slog.Logger) *CacheProvider {
+	if logger == nil {
+		logger = newDiscardLogger()
+	}
+	c.logger = logger
+	return c
+}
+
+// WithBypass disables the cache. Requests are sent to the provider
+// and their responses are not stored.
+func (c *CacheProvider) WithBypass(bypass bool) *CacheProvider {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bypass = bypass
+	return c
+}
+
+type bypassCacheKey struct{}
+
+// WithoutCache returns a context which bypasses all CacheProviders for
+// the requests made with it.
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassCacheKey{}, true)
+}
+
+// Stats returns the amount of cache hits and misses.
+func (c *CacheProvider) Stats() (hits, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// Model returns the model set with WithModel or the model of the
+// wrapped provider.
+func (c *CacheProvider) Model() string {
+	if c.model != "" {
+		return c.model
+	}
+	if modeler, ok := c.provider.(Modeler); ok {
+		return modeler.Model()
+	}
+	return ""
+}
+
+func (c *CacheProvider) Request(ctx context.Context, system, prompt string) (string, error) {
+	return c.cached(ctx, map[string]any{
+		"system": system,
+		"prompt": prompt,
+	}, func() (string, error) {
+		return c.provider.Request(ctx, system, prompt)
+	})
+}
+
+func (c *CacheProvider) Chat(ctx context.Context, messages []Message) (string, error) {
+	return c.cached(ctx, map[string]any{
+		"messages": messages,
+	}, func() (string, error) {
+		return asChatProvider(c.provider).Chat(ctx, messages)
+	})
+}
+
+// RequestTools is passed to the provider without caching.
+func (c *CacheProvider) RequestTools(ctx context.Context, messages []Message, tools []Tool) (Message, error) {
+	toolProvider, ok := c.provider.(ToolProvider)
+	if !ok {
+		return Message{}, ErrToolsNotSupported
+	}
+	return toolProvider.RequestTools(ctx, messages, tools)
+}
+
+func (c *CacheProvider) cached(ctx context.Context, request map[string]any, send func() (string, error)) (string, error) {
+	c.mu.Lock()
+	bypass := c.bypass
+	c.mu.Unlock()
+	if bypass || ctx.Value(bypassCacheKey{}) != nil {
+		return send()
+	}
+
+	request["model"] = c.Model()
+	key, err := cacheKey(request)
+	if err != nil {
+		return "", err
+	}
+	if response, ok := c.get(key); ok {
+		// cached responses do not cost any tokens
+		ReportUsage(ctx, Usage{Model: c.Model()})
+		return response, nil
+	}
+	response, err := send()
+	if err != nil {
+		return "", err
+	}
+	if err := c.put(key, response); err != nil {
+		// the response is valid even when it cannot be cached
+		c.logger.WarnContext(ctx, "failed to cache response", "error", err)
+	}
+	return response, nil
+}
+
+func cacheKey(request map[string]any) (string, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to create cache key: %w", err)
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+func (c *CacheProvider) get(key string) (string, bool) {
+	c.mu.Lock()
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*cacheEntry)
+		if c.valid(entry) {
+			c.lru.MoveToFront(element)
+			c.hits++
+			c.mu.Unlock()
+			return entry.Response, true
+		}
+		c.lru.Remove(element)
+		delete(c.entries, key)
+	}
+	c.mu.Unlock()
+
+	entry, ok := c.load(key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !ok {
+		c.misses++
+		return "", false
+	}
+	c.add(entry)
+	c.hits++
+	return entry.Response, true
+}
+
+// put adds the response to the cache. The error of storing it on disk
+// is returned after the response was added to the memory.
+func (c *CacheProvider) put(key, response string) error {
+	entry := &cacheEntry{
+		Key:      key,
+		Response: response,
+		Created:  c.clock(),
+	}
+	c.mu.Lock()
+	c.add(entry)
+	c.mu.Unlock()
+	return c.store(entry)
+}
+
+// add inserts the entry into the LRU list and evicts the least
+// recently used entry when the capacity is exceeded.
+func (c *CacheProvider) add(entry *cacheEntry) {
+	if element, ok := c.entries[entry.Key]; ok {
+		element.Value = entry
+		c.lru.MoveToFront(element)
+		return
+	}
+	c.entries[entry.Key] = c.lru.PushFront(entry)
+	if c.capacity > 0 && c.lru.Len() > c.capacity {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).Key)
+	}
+}
+
+func (c *CacheProvider) valid(entry *cacheEntry) bool {
+	return c.ttl <= 0 || c.clock().Sub(entry.Created) < c.ttl
+}
+
+func (c *CacheProvider) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+func (c *CacheProvider) load(key string) (*cacheEntry, bool) {
+	if c.dir == "" {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || !c.valid(&entry) {
+		// expired and broken files are not read again
+		os.Remove(c.path(key))
+		return nil, false
+	}
+	return &entry, true
+}
+
+func (c *CacheProvider) store(entry *cacheEntry) error {
+	if c.dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil && !errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+	// concurrent requests might store the same key, the rename
+	// replaces the file atomically
+	file, err := os.CreateTemp(c.dir, entry.Key+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to store cache entry: %w", err)
+	}
+	if err = file.Chmod(0644); err == nil {
+		_, err = file.Write(data)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), c.path(entry.Key))
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return fmt.Errorf("failed to store cache entry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package goreact
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCacheProviderLRU(t *testing.T) {
+	llm := &countingProvider{}
+	cache := NewCacheProvider(llm, 2)
+	ctx := context.Background()
+	tests := []struct {
+		prompt   string
+		response string
+	}{
+		{"a", "response 1"},
+		{"b", "response 2"},
+		{"a", "response 1"},
+		// c evicts b as a was used more recently
+		{"c", "response 3"},
+		{"a", "response 1"},
+		{"b", "response 4"},
+	}
+	for _, test := range tests {
+		response, err := cache.Request(ctx, "system", test.prompt)
+		if err != nil || response != test.response {
+			t.Errorf("request %s: expected %q, got %q, %v", test.prompt, test.response, response, err)
+		}
+	}
+	if hits, misses := cache.Stats(); hits != 2 || misses != 4 {
+		t.Errorf("expected 2 hits and 4 misses, got %d and %d", hits, misses)
+	}
+}
+
+func TestCacheProviderTTL(t *testing.T) {
+	now := time.Now()
+	llm := &countingProvider{}
+	cache := NewCacheProvider(llm, 0).WithTTL(time.Minute)
+	cache.clock = func() time.Time { return now }
+	ctx := context.Background()
+	tests := []struct {
+		after    time.Duration
+		response string
+	}{
+		{0, "response 1"},
+		{30 * time.Second, "response 1"},
+		{30 * time.Second, "response 2"},
+		{59 * time.Second, "response 2"},
+	}
+	for _, test := range tests {
+		now = now.Add(test.after)
+		response, err := cache.Request(ctx, "system", "prompt")
+		if err != nil || response != test.response {
+			t.Errorf("after %v: expected %q, got %q, %v", test.after, test.response, response, err)
+		}
+	}
+}
+
+func TestCacheProviderDir(t *testing.T) {
+	now := time.Now()
+	dir := t.TempDir()
+	ctx := context.Background()
+	newCache := func(llm LLMProvider) *CacheProvider {
+		cache := NewCacheProvider(llm, 0).WithDir(dir).WithTTL(time.Hour)
+		cache.clock = func() time.Time { return now }
+		return cache
+	}
+	if _, err := newCache(&countingProvider{}).Request(ctx, "system", "prompt"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a new cache finds the response on disk
+	llm := &countingProvider{}
+	response, err := newCache(llm).Request(ctx, "system", "prompt")
+	if err != nil || response != "response 1" || llm.requests != 0 {
+		t.Fatalf("expected the stored response, got %q, %v after %d requests", response, err, llm.requests)
+	}
+
+	// an expired file is removed
+	now = now.Add(time.Hour)
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 1 {
+		t.Fatalf("expected 1 file, got %v", files)
+	}
+	cache := newCache(&scriptedProvider{})
+	if _, err := cache.Request(ctx, "system", "prompt"); err == nil {
+		t.Fatal("expected the request to be sent to the provider")
+	}
+	if _, err := os.Stat(files[0]); !os.IsNotExist(err) {
+		t.Errorf("expected the expired file to be removed, got %v", err)
+	}
+}
+
+func TestCacheProviderStoreFailure(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	llm := &countingProvider{}
+	// the directory cannot be created below a file
+	cache := NewCacheProvider(llm, 0).WithDir(filepath.Join(file, "cache"))
+	for i := 0; i < 2; i++ {
+		response, err := cache.Request(context.Background(), "system", "prompt")
+		if err != nil || response != "response 1" {
+			t.Fatalf("expected the response despite the failure, got %q, %v", response, err)
+		}
+	}
+}
+
+func TestCacheProviderBypass(t *testing.T) {
+	tests := []struct {
+		name  string
+		cache func(llm LLMProvider) *CacheProvider
+		ctx   context.Context
+	}{
+		{"WithBypass", func(llm LLMProvider) *CacheProvider {
+			return NewCacheProvider(llm, 0).WithBypass(true)
+		}, context.Background()},
+		{"WithoutCache", func(llm LLMProvider) *CacheProvider {
+			return NewCacheProvider(llm, 0)
+		}, WithoutCache(context.Background())},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			llm := &countingProvider{}
+			cache := test.cache(llm)
+			for _, want := range []string{"response 1", "response 2"} {
+				response, err := cache.Request(test.ctx, "system", "prompt")
+				if err != nil || response != want {
+					t.Errorf("expected %q, got %q, %v", want, response, err)
+				}
+			}
+			if hits, misses := cache.Stats(); hits != 0 || misses != 0 {
+				t.Errorf("expected the cache to be unused, got %d hits and %d misses", hits, misses)
+			}
+			// the bypassed responses were not stored
+			cache.WithBypass(false)
+			if response, _ := cache.Request(context.Background(), "system", "prompt"); response != "response 3" {
+				t.Errorf("expected a new response, got %q", response)
+			}
+		})
+	}
+}
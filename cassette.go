@@ -0,0 +1,270 @@
+package goreact
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrNoRecording is returned by the ReplayProvider when a request was
+// not recorded in the cassette.
+var ErrNoRecording = errors.New("no recorded response")
+
+// Kinds of recorded interactions.
+const (
+	interactionRequest = "request"
+	interactionChat    = "chat"
+	interactionTools   = "tools"
+)
+
+// Interaction is a recorded request to an LLM together with its
+// response.
+type Interaction struct {
+	Kind string `json:"kind"`
+	// System and Prompt are set for Request.
+	System string `json:"system,omitempty"`
+	Prompt string `json:"prompt,omitempty"`
+	// Messages are set for Chat and RequestTools.
+	Messages []Message `json:"messages,omitempty"`
+	// Tools contains the names of the tools for RequestTools.
+	Tools    []string `json:"tools,omitempty"`
+	Response Message  `json:"response"`
+}
+
+// Cassette is a file with recorded interactions.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// LoadCassette reads a cassette file.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("failed to decode cassette %s: %w", path, err)
+	}
+	return &cassette, nil
+}
+
+// Save writes the cassette to a file.
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// key identifies the request of an interaction.
+func (i Interaction) key() string {
+	data, _ := json.Marshal(Interaction{
+		Kind:     i.Kind,
+		System:   i.System,
+		Prompt:   i.Prompt,
+		Messages: i.Messages,
+		Tools:    i.Tools,
+	})
+	return string(data)
+}
+
+// RecordingProvider wraps an LLMProvider and records all successful
+// requests and their responses in a cassette file which can be
+// replayed by the ReplayProvider. The file is written after each
+// request.
+type RecordingProvider struct {
+	provider LLMProvider
+	path     string
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+func NewRecordingProvider(provider LLMProvider, path string) *RecordingProvider {
+	return &RecordingProvider{
+		provider: provider,
+		path:     path,
+	}
+}
+
+func (r *RecordingProvider) Request(ctx context.Context, system, prompt string) (string, error) {
+	response, err := r.provider.Request(ctx, system, prompt)
+	if err != nil {
+		return "", err
+	}
+	return response, r.record(Interaction{
+		Kind:     interactionRequest,
+		System:   system,
+		Prompt:   prompt,
+		Response: Message{Role: RoleAssistant, Content: response},
+	})
+}
+
+func (r *RecordingProvider) Chat(ctx context.Context, messages []Message) (string, error) {
+	response, err := asChatProvider(r.provider).Chat(ctx, messages)
+	if err != nil {
+		return "", err
+	}
+	return response, r.record(Interaction{
+		Kind:     interactionChat,
+		Messages: messages,
+		Response: Message{Role: RoleAssistant, Content: response},
+	})
+}
+
+func (r *RecordingProvider) RequestTools(ctx context.Context, messages []Message, tools []Tool) (Message, error) {
+	toolProvider, ok := r.provider.(ToolProvider)
+	if !ok {
+		return Message{}, ErrToolsNotSupported
+	}
+	response, err := toolProvider.RequestTools(ctx, messages, tools)
+	if err != nil {
+		return Message{}, err
+	}
+	return response, r.record(Interaction{
+		Kind:     interactionTools,
+		Messages: messages,
+		Tools:    toolNames(tools),
+		Response: response,
+	})
+}
+
+func (r *RecordingProvider) record(interaction Interaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	if err := r.cassette.Save(r.path); err != nil {
+		return fmt.Errorf("failed to record interaction: %w", err)
+	}
+	return nil
+}
+
+// NewCassetteProvider replays the cassette at path when the file
+// exists. Otherwise all requests are sent to provider and recorded
+// into the file. This turns a program into a reproducible test after
+// the first run.
+func NewCassetteProvider(provider LLMProvider, path string) (LLMProvider, error) {
+	if _, err := os.Stat(path); err == nil {
+		return NewReplayProvider(path)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to check cassette: %w", err)
+	}
+	return NewRecordingProvider(provider, path), nil
+}
+
+// ReplayProvider answers requests with the responses recorded in a
+// cassette without contacting an LLM. Identical requests get their
+// responses in the recorded order. Requests which were not recorded
+// fail with ErrNoRecording.
+type ReplayProvider struct {
+	mu        sync.Mutex
+	responses map[string][]Message
+	tools     bool
+}
+
+// NewReplayProvider loads the cassette file written by a
+// RecordingProvider.
+func NewReplayProvider(path string) (*ReplayProvider, error) {
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayProviderFromCassette(cassette), nil
+}
+
+func NewReplayProviderFromCassette(cassette *Cassette) *ReplayProvider {
+	r := &ReplayProvider{
+		responses: make(map[string][]Message),
+	}
+	for _, interaction := range cassette.Interactions {
+		key := interaction.key()
+		r.responses[key] = append(r.responses[key], interaction.Response)
+		if interaction.Kind == interactionTools {
+			r.tools = true
+		}
+	}
+	return r
+}
+
+func (r *ReplayProvider) Request(ctx context.Context, system, prompt string) (string, error) {
+	response, err := r.replay(Interaction{
+		Kind:   interactionRequest,
+		System: system,
+		Prompt: prompt,
+	})
+	return response.Content, err
+}
+
+func (r *ReplayProvider) Chat(ctx context.Context, messages []Message) (string, error) {
+	response, err := r.replay(Interaction{
+		Kind:     interactionChat,
+		Messages: messages,
+	})
+	return response.Content, err
+}
+
+// RequestTools replays a tool request. ErrToolsNotSupported is
+// returned when no tool request was recorded.
+func (r *ReplayProvider) RequestTools(ctx context.Context, messages []Message, tools []Tool) (Message, error) {
+	if !r.tools {
+		return Message{}, ErrToolsNotSupported
+	}
+	return r.replay(Interaction{
+		Kind:     interactionTools,
+		Messages: messages,
+		Tools:    toolNames(tools),
+	})
+}
+
+// Remaining returns the amount of recorded responses which were not
+// replayed yet.
+func (r *ReplayProvider) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	remaining := 0
+	for _, responses := range r.responses {
+		remaining += len(responses)
+	}
+	return remaining
+}
+
+func (r *ReplayProvider) replay(interaction Interaction) (Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := interaction.key()
+	responses := r.responses[key]
+	if len(responses) == 0 {
+		return Message{}, fmt.Errorf("%w for %s request: %s", ErrNoRecording,
+			interaction.Kind, abbreviate(lastContent(interaction), 200))
+	}
+	r.responses[key] = responses[1:]
+	return responses[0], nil
+}
+
+func lastContent(interaction Interaction) string {
+	if len(interaction.Messages) > 0 {
+		return interaction.Messages[len(interaction.Messages)-1].Content
+	}
+	return interaction.Prompt
+}
+
+func abbreviate(text string, max int) string {
+	text = strings.ReplaceAll(text, "\n", " ")
+	if len(text) <= max {
+		return text
+	}
+	return text[:max] + "..."
+}
+
+func toolNames(tools []Tool) []string {
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+	return names
+}
@@ -0,0 +1,106 @@
+package goreact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// countingProvider answers every request with a numbered response.
+type countingProvider struct {
+	requests int
+}
+
+func (c *countingProvider) Request(ctx context.Context, system, prompt string) (string, error) {
+	c.requests++
+	return fmt.Sprintf("response %d", c.requests), nil
+}
+
+func TestCassetteRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	ctx := context.Background()
+	messages := []Message{
+		{Role: RoleSystem, Content: "system"},
+		{Role: RoleUser, Content: "QUESTION: coins?"},
+	}
+
+	recorder := NewRecordingProvider(&countingProvider{}, path)
+	for _, step := range []func() (string, error){
+		func() (string, error) { return recorder.Request(ctx, "system", "same") },
+		func() (string, error) { return recorder.Chat(ctx, messages) },
+		func() (string, error) { return recorder.Request(ctx, "system", "same") },
+	} {
+		if _, err := step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	replay, err := NewReplayProvider(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remaining := replay.Remaining(); remaining != 3 {
+		t.Fatalf("expected 3 recorded responses, got %d", remaining)
+	}
+	// identical requests get their responses in the recorded order
+	for _, want := range []string{"response 1", "response 3"} {
+		response, err := replay.Request(ctx, "system", "same")
+		if err != nil || response != want {
+			t.Errorf("expected %q, got %q, %v", want, response, err)
+		}
+	}
+	if remaining := replay.Remaining(); remaining != 1 {
+		t.Errorf("expected 1 remaining response, got %d", remaining)
+	}
+	response, err := replay.Chat(ctx, messages)
+	if err != nil || response != "response 2" {
+		t.Errorf("expected response 2, got %q, %v", response, err)
+	}
+	if remaining := replay.Remaining(); remaining != 0 {
+		t.Errorf("expected no remaining responses, got %d", remaining)
+	}
+}
+
+func TestReplayProviderNoRecording(t *testing.T) {
+	ctx := context.Background()
+	replay := NewReplayProviderFromCassette(&Cassette{Interactions: []Interaction{{
+		Kind:     interactionRequest,
+		System:   "system",
+		Prompt:   "prompt",
+		Response: Message{Role: RoleAssistant, Content: "ok"},
+	}}})
+
+	tests := []struct {
+		name    string
+		request func() error
+	}{
+		{"other prompt", func() error {
+			_, err := replay.Request(ctx, "system", "other")
+			return err
+		}},
+		{"other kind", func() error {
+			_, err := replay.Chat(ctx, []Message{{Role: RoleUser, Content: "prompt"}})
+			return err
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.request(); !errors.Is(err, ErrNoRecording) {
+				t.Errorf("expected ErrNoRecording, got %v", err)
+			}
+		})
+	}
+
+	if _, err := replay.Request(ctx, "system", "prompt"); err != nil {
+		t.Fatal(err)
+	}
+	// the response is used up
+	if _, err := replay.Request(ctx, "system", "prompt"); !errors.Is(err, ErrNoRecording) {
+		t.Errorf("expected ErrNoRecording for a replayed request, got %v", err)
+	}
+	if _, err := replay.RequestTools(ctx, nil, nil); !errors.Is(err, ErrToolsNotSupported) {
+		t.Errorf("expected ErrToolsNotSupported without recorded tool requests, got %v", err)
+	}
+}
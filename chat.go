@@ -0,0 +1,53 @@
+package goreact
+
+import (
+	"context"
+	"strings"
+)
+
+// ChatProvider is implemented by LLM providers which accept a whole
+// conversation of role-tagged messages. The first message is usually
+// the system prompt which stays the same during a question so that
+// providers can cache it.
+type ChatProvider interface {
+	Chat(ctx context.Context, messages []Message) (string, error)
+}
+
+// NewChatAdapter turns an LLMProvider into a ChatProvider. The system
+// messages become the system prompt and all other messages are joined
+// into the user prompt.
+func NewChatAdapter(provider LLMProvider) ChatProvider {
+	return &chatAdapter{provider: provider}
+}
+
+type chatAdapter struct {
+	provider LLMProvider
+}
+
+func (a *chatAdapter) Chat(ctx context.Context, messages []Message) (string, error) {
+	system, prompt := flattenMessages(messages)
+	return a.provider.Request(ctx, system, prompt)
+}
+
+// flattenMessages returns the system prompt and the user prompt
+// which contains the content of all other messages.
+func flattenMessages(messages []Message) (string, string) {
+	var system, prompt []string
+	for _, message := range messages {
+		if message.Role == RoleSystem {
+			system = append(system, message.Content)
+			continue
+		}
+		prompt = append(prompt, message.Content)
+	}
+	return strings.Join(system, "\n"), strings.Join(prompt, "\n") + "\n"
+}
+
+// asChatProvider returns the provider itself when it is a
+// ChatProvider and wraps it by an adapter otherwise.
+func asChatProvider(provider LLMProvider) ChatProvider {
+	if chat, ok := provider.(ChatProvider); ok {
+		return chat
+	}
+	return NewChatAdapter(provider)
+}
@@ -0,0 +1,471 @@
+// goreact-gen generates goreact commands from the exported functions of
+// a package which are marked with the //goreact:command directive:
+//
+//	// SearchIssues searches the issue tracker for issues matching the query.
+//	//
+//	//goreact:command
+//	func SearchIssues(ctx context.Context, query string, maxResults int) ([]Issue, error)
+//
+// The doc comment becomes the description of the command and the
+// parameter names become the names and descriptions of its arguments.
+// A function with a single parameter whose underlying type is a struct
+// uses it as input so that the struct tags of goreact.NewCommand
+// apply. A time.Duration parameter is a string like "1m30s" which is
+// parsed with time.ParseDuration. The package is type-checked from
+// source for that. The name of the
+// command is the snake case function name unless given after the
+// directive, like //goreact:command search.
+//
+// Add to a file of the package:
+//
+//	//go:generate go run github.com/dgruber/goreact/cmd/goreact-gen
+//
+// The generated file contains a function which returns the commands as
+// map[string]goreact.Command for goreact.NewReact.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const directive = "//goreact:command"
+
+func main() {
+	dir := flag.String("dir", ".", "directory of the package")
+	output := flag.String("output", "goreact_commands.go", "name of the generated file in the package directory")
+	function := flag.String("func", "Commands", "name of the generated function returning the commands")
+	flag.Parse()
+
+	if err := generate(*dir, *output, *function); err != nil {
+		fmt.Fprintf(os.Stderr, "goreact-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// command is a function with the directive.
+type command struct {
+	name        string
+	description string
+	function    string
+	// context is true when the first parameter is a context.Context.
+	context bool
+	// input is the type of the single parameter when its underlying
+	// type is a struct. When it is empty the parameters are turned
+	// into a generated struct.
+	input   string
+	params  []param
+	results int
+	// error is true when the last result is an error.
+	error bool
+}
+
+type param struct {
+	name string
+	typ  string
+	// duration is true for a time.Duration which is sent as string.
+	duration bool
+}
+
+func generate(dir, output, function string) error {
+	source, err := generateSource(dir, output, function)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, output), source, 0644)
+}
+
+// generateSource returns the generated file for the package in dir.
+// The package is type-checked from source, without the generated file,
+// for resolving the kinds of the parameter types and the names of
+// their packages.
+func generateSource(dir, output, function string) ([]byte, error) {
+	pkg, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range pkg.GoFiles {
+		if name == output {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	var typeErrors []error
+	config := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		// other files of the package might refer to the generated
+		// function, only the types of the signatures must be valid
+		Error: func(err error) { typeErrors = append(typeErrors, err) },
+	}
+	info := &types.Info{Defs: map[*ast.Ident]types.Object{}}
+	checked, _ := config.Check(pkg.Name, fset, files, info)
+
+	imports := &importSet{pkg: checked, paths: map[string]string{}}
+	var commands []command
+	for _, file := range files {
+		found, err := commandsOfFile(fset, file, info, imports)
+		if err != nil {
+			if len(typeErrors) > 0 {
+				return nil, fmt.Errorf("%w (%v)", err, typeErrors[0])
+			}
+			return nil, err
+		}
+		commands = append(commands, found...)
+	}
+	if imports.err != nil {
+		return nil, imports.err
+	}
+	if len(commands) == 0 {
+		return nil, fmt.Errorf("no function with %s found in %s", directive, dir)
+	}
+	sort.Slice(commands, func(i, j int) bool { return commands[i].name < commands[j].name })
+	for i := 1; i < len(commands); i++ {
+		if commands[i].name == commands[i-1].name {
+			return nil, fmt.Errorf("the command %s is defined twice", commands[i].name)
+		}
+	}
+	return render(pkg.Name, function, commands, imports.paths)
+}
+
+// importSet collects the imports which are needed for the parameter
+// types by their package names.
+type importSet struct {
+	pkg   *types.Package
+	paths map[string]string
+	err   error
+}
+
+// qualify is the types.Qualifier of the generated file.
+func (s *importSet) qualify(pkg *types.Package) string {
+	if pkg == s.pkg {
+		return ""
+	}
+	if existing, ok := s.paths[pkg.Name()]; ok && existing != pkg.Path() && s.err == nil {
+		s.err = fmt.Errorf("the package name %s refers to %s and %s", pkg.Name(), existing, pkg.Path())
+	}
+	s.paths[pkg.Name()] = pkg.Path()
+	return pkg.Name()
+}
+
+// commandsOfFile returns the commands of the file.
+func commandsOfFile(fset *token.FileSet, file *ast.File, info *types.Info, imports *importSet) ([]command, error) {
+	var commands []command
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Doc == nil {
+			continue
+		}
+		name, ok := directiveName(fn.Doc)
+		if !ok {
+			continue
+		}
+		position := fset.Position(fn.Pos())
+		if fn.Recv != nil || !fn.Name.IsExported() {
+			return nil, fmt.Errorf("%s: %s must be an exported function", position, fn.Name.Name)
+		}
+		if fn.Type.TypeParams != nil {
+			return nil, fmt.Errorf("%s: %s must not be generic", position, fn.Name.Name)
+		}
+		c := command{
+			name:        name,
+			description: strings.Join(strings.Fields(fn.Doc.Text()), " "),
+			function:    fn.Name.Name,
+		}
+		if c.name == "" {
+			c.name = snakeCase(fn.Name.Name)
+		}
+		if c.description == "" {
+			return nil, fmt.Errorf("%s: %s has no doc comment for the description", position, fn.Name.Name)
+		}
+		object, ok := info.Defs[fn.Name].(*types.Func)
+		if !ok {
+			return nil, fmt.Errorf("%s: %s has no type", position, fn.Name.Name)
+		}
+		if err := c.parseSignature(object.Type().(*types.Signature), imports.qualify); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", position, fn.Name.Name, err)
+		}
+		commands = append(commands, c)
+	}
+	return commands, nil
+}
+
+// directiveName returns the optional command name of the directive.
+func directiveName(doc *ast.CommentGroup) (string, bool) {
+	for _, comment := range doc.List {
+		if comment.Text == directive {
+			return "", true
+		}
+		if rest, ok := strings.CutPrefix(comment.Text, directive+" "); ok {
+			return strings.TrimSpace(rest), true
+		}
+	}
+	return "", false
+}
+
+func (c *command) parseSignature(signature *types.Signature, qualify types.Qualifier) error {
+	if signature.Variadic() {
+		return fmt.Errorf("variadic parameters are not supported")
+	}
+	var params []*types.Var
+	for i := 0; i < signature.Params().Len(); i++ {
+		params = append(params, signature.Params().At(i))
+	}
+	if len(params) > 0 && isContext(params[0].Type()) {
+		c.context = true
+		params = params[1:]
+	}
+	for _, p := range params {
+		if p.Name() == "" || p.Name() == "_" {
+			return fmt.Errorf("parameters must be named")
+		}
+		if !valid(p.Type()) {
+			return fmt.Errorf("the type of the parameter %s is invalid", p.Name())
+		}
+		if isDuration(p.Type()) {
+			c.params = append(c.params, param{name: p.Name(), typ: "string", duration: true})
+			continue
+		}
+		c.params = append(c.params, param{name: p.Name(), typ: types.TypeString(p.Type(), qualify)})
+	}
+	// goreact.NewCommand needs a struct as input, other types like
+	// time.Duration become a field of the generated struct
+	if len(params) == 1 {
+		if _, ok := params[0].Type().Underlying().(*types.Struct); ok {
+			c.input = c.params[0].typ
+		}
+	}
+
+	results := signature.Results()
+	c.results = results.Len()
+	if c.results > 0 && types.Identical(results.At(c.results-1).Type(), errorType) {
+		c.error = true
+	}
+	if c.results > 2 || (c.results == 2 && !c.error) {
+		return fmt.Errorf("the results must be (T, error), (T), (error), or none")
+	}
+	return nil
+}
+
+var errorType = types.Universe.Lookup("error").Type()
+
+func isContext(typ types.Type) bool {
+	return isNamed(typ, "context", "Context")
+}
+
+func isDuration(typ types.Type) bool {
+	return isNamed(typ, "time", "Duration")
+}
+
+func isNamed(typ types.Type, path, name string) bool {
+	named, ok := typ.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == path &&
+		named.Obj().Name() == name
+}
+
+func (c command) durations() bool {
+	for _, p := range c.params {
+		if p.duration {
+			return true
+		}
+	}
+	return false
+}
+
+// valid returns false when the type could not be resolved, e.g. due
+// to a missing import.
+func valid(typ types.Type) bool {
+	switch t := typ.(type) {
+	case *types.Basic:
+		return t.Kind() != types.Invalid
+	case *types.Pointer:
+		return valid(t.Elem())
+	case *types.Slice:
+		return valid(t.Elem())
+	case *types.Array:
+		return valid(t.Elem())
+	case *types.Map:
+		return valid(t.Key()) && valid(t.Elem())
+	}
+	return true
+}
+
+func render(pkg, function string, commands []command, imports map[string]string) ([]byte, error) {
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by goreact-gen. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+
+	required := map[string]string{"context": "context", "goreact": "github.com/dgruber/goreact"}
+	for _, c := range commands {
+		if c.durations() {
+			required["fmt"] = "fmt"
+			required["time"] = "time"
+		}
+	}
+	for name, path := range required {
+		if existing, ok := imports[name]; ok && existing != path {
+			return nil, fmt.Errorf("the package name %s refers to %s and %s", name, existing, path)
+		}
+		imports[name] = path
+	}
+	// standard library packages first like goimports does
+	var std, other []string
+	for name, path := range imports {
+		spec := fmt.Sprintf("%s %q", name, path)
+		if path[strings.LastIndex(path, "/")+1:] == name {
+			spec = strconv.Quote(path)
+		}
+		if strings.Contains(strings.Split(path, "/")[0], ".") {
+			other = append(other, spec)
+		} else {
+			std = append(std, spec)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+	fmt.Fprintf(&src, "import (\n%s\n\n%s\n)\n\n", strings.Join(std, "\n"), strings.Join(other, "\n"))
+
+	for _, c := range commands {
+		if c.input != "" {
+			continue
+		}
+		if len(c.params) == 0 {
+			fmt.Fprintf(&src, "type %s struct{}\n\n", c.argsType())
+			continue
+		}
+		fmt.Fprintf(&src, "type %s struct {\n", c.argsType())
+		for _, p := range c.params {
+			desc := words(p.name)
+			if p.duration {
+				desc += " like 1m30s"
+			}
+			fmt.Fprintf(&src, "\t%s %s `json:%q desc:%q`\n", fieldName(p.name), p.typ, p.name, desc)
+		}
+		src.WriteString("}\n\n")
+	}
+
+	fmt.Fprintf(&src, "// %s returns the commands of the functions with the %s directive.\n", function, directive)
+	fmt.Fprintf(&src, "func %s() map[string]goreact.Command {\n\treturn goreact.Commands(\n", function)
+	for _, c := range commands {
+		input := c.input
+		var args []string
+		if c.context {
+			args = append(args, "ctx")
+		}
+		fmt.Fprintf(&src, "\t\tgoreact.NewCommand(%q, %q,\n", c.name, c.description)
+		if input == "" {
+			input = c.argsType()
+			fmt.Fprintf(&src, "\t\t\tfunc(ctx context.Context, in %s) (any, error) {\n", input)
+			for _, p := range c.params {
+				if !p.duration {
+					args = append(args, "in."+fieldName(p.name))
+					continue
+				}
+				// the local variable must not shadow what the closure uses
+				local := p.name
+				for local == "ctx" || local == "in" || local == "err" || local == c.function || imports[local] != "" {
+					local += "Value"
+				}
+				fmt.Fprintf(&src, "\t\t\t\t%s, err := time.ParseDuration(in.%s)\n", local, fieldName(p.name))
+				fmt.Fprintf(&src, "\t\t\t\tif err != nil {\n\t\t\t\t\treturn nil, fmt.Errorf(\"%%w: %s: %%v\", goreact.ErrInvalidArguments, err)\n\t\t\t\t}\n", p.name)
+				args = append(args, local)
+			}
+		} else {
+			args = append(args, "in")
+			fmt.Fprintf(&src, "\t\t\tfunc(ctx context.Context, in %s) (any, error) {\n", input)
+		}
+		call := fmt.Sprintf("%s(%s)", c.function, strings.Join(args, ", "))
+		switch {
+		case c.results == 2:
+			fmt.Fprintf(&src, "\t\t\t\treturn %s\n", call)
+		case c.results == 1 && c.error:
+			fmt.Fprintf(&src, "\t\t\t\treturn \"OK\", %s\n", call)
+		case c.results == 1:
+			fmt.Fprintf(&src, "\t\t\t\treturn %s, nil\n", call)
+		default:
+			fmt.Fprintf(&src, "\t\t\t\t%s\n\t\t\t\treturn \"OK\", nil\n", call)
+		}
+		src.WriteString("\t\t\t}),\n")
+	}
+	src.WriteString("\t)\n}\n")
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("invalid generated code: %w\n%s", err, src.String())
+	}
+	return formatted, nil
+}
+
+func (c command) argsType() string {
+	name := []rune(c.function)
+	name[0] = unicode.ToLower(name[0])
+	return string(name) + "Args"
+}
+
+// fieldName returns the exported field name of a parameter with the
+// initialisms of Go like ID and URL in upper case.
+func fieldName(name string) string {
+	var field strings.Builder
+	for _, word := range strings.Fields(words(name)) {
+		if initialisms[word] {
+			field.WriteString(strings.ToUpper(word))
+			continue
+		}
+		if plural, ok := strings.CutSuffix(word, "s"); ok && initialisms[plural] {
+			field.WriteString(strings.ToUpper(plural) + "s")
+			continue
+		}
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		field.WriteString(string(runes))
+	}
+	return field.String()
+}
+
+// initialisms are the common initialisms of golint.
+var initialisms = map[string]bool{
+	"acl": true, "api": true, "ascii": true, "cpu": true, "css": true, "dns": true,
+	"eof": true, "guid": true, "html": true, "http": true, "https": true, "id": true,
+	"ip": true, "json": true, "lhs": true, "qps": true, "ram": true, "rhs": true,
+	"rpc": true, "sla": true, "smtp": true, "sql": true, "ssh": true, "tcp": true,
+	"tls": true, "ttl": true, "udp": true, "ui": true, "uid": true, "uuid": true,
+	"uri": true, "url": true, "utf8": true, "vm": true, "xml": true, "xmpp": true,
+	"xsrf": true, "xss": true,
+}
+
+// words splits a camel case name into lower case words.
+func words(name string) string {
+	var result []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) &&
+			(unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			result = append(result, ' ')
+		}
+		result = append(result, unicode.ToLower(r))
+	}
+	return string(result)
+}
+
+func snakeCase(name string) string {
+	return strings.ReplaceAll(words(name), " ", "_")
+}
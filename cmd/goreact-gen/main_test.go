@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/dgruber/goreact"
+	"github.com/dgruber/goreact/cmd/goreact-gen/testdata/example"
+)
+
+var update = flag.Bool("update", false, "update the golden file")
+
+func TestGenerateGolden(t *testing.T) {
+	dir := filepath.Join("testdata", "example")
+	golden := filepath.Join(dir, "goreact_commands.go")
+	source, err := generateSource(dir, "goreact_commands.go", "Commands")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *update {
+		if err := os.WriteFile(golden, source, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(source) != string(expected) {
+		t.Errorf("the generated code differs from %s, run go test -update:\n%s", golden, source)
+	}
+}
+
+// TestGeneratedCommands runs the golden file which is compiled as part
+// of the test.
+func TestGeneratedCommands(t *testing.T) {
+	commands := example.Commands()
+	parameters := map[string][]goreact.Parameter{}
+	for name, command := range commands {
+		parameters[name] = command.Parameters
+	}
+	tests := []struct {
+		command string
+		types   []goreact.ParameterType
+	}{
+		{"count", []goreact.ParameterType{goreact.TypeString, goreact.TypeInteger}},
+		{"distance", []goreact.ParameterType{goreact.TypeObject, goreact.TypeObject, goreact.TypeString}},
+		{"lookup_document", []goreact.ParameterType{goreact.TypeInteger}},
+		{"reset", nil},
+		{"search", []goreact.ParameterType{goreact.TypeString, goreact.TypeInteger}},
+		{"wait", []goreact.ParameterType{goreact.TypeString}},
+	}
+	if len(commands) != len(tests) {
+		t.Errorf("expected %d commands, got %d", len(tests), len(commands))
+	}
+	for _, test := range tests {
+		var types []goreact.ParameterType
+		for _, parameter := range parameters[test.command] {
+			types = append(types, parameter.Type)
+		}
+		if !slices.Equal(types, test.types) {
+			t.Errorf("%s: expected parameter types %v, got %v", test.command, test.types, types)
+		}
+	}
+
+	// struct parameters are objects with their fields
+	point := []goreact.Parameter{
+		{Name: "lat", Type: goreact.TypeNumber, Required: true},
+		{Name: "lon", Type: goreact.TypeNumber, Required: true},
+	}
+	if from := parameters["distance"][0]; fmt.Sprint(from.Properties) != fmt.Sprint(point) {
+		t.Errorf("expected the properties %v, got %v", point, from.Properties)
+	}
+}
+
+func TestGeneratedDuration(t *testing.T) {
+	wait := example.Commands()["wait"]
+	tests := []struct {
+		duration    string
+		observation string
+		err         error
+	}{
+		{"1ms", "OK", nil},
+		// the LLM can correct an invalid duration
+		{"1 minute", "The arguments of wait are invalid", goreact.ErrInvalidArguments},
+	}
+	for _, test := range tests {
+		observation, err := wait.TypedFunc(context.Background(), goreact.Arguments{"duration": test.duration})
+		if !errors.Is(err, test.err) || !strings.HasPrefix(observation, test.observation) {
+			t.Errorf("%s: unexpected observation %q, %v", test.duration, observation, err)
+		}
+	}
+}
+
+func TestFieldName(t *testing.T) {
+	tests := map[string]string{
+		"query":      "Query",
+		"maxResults": "MaxResults",
+		"id":         "ID",
+		"userID":     "UserID",
+		"userId":     "UserID",
+		"ids":        "IDs",
+		"baseURL":    "BaseURL",
+		"httpURL":    "HTTPURL",
+	}
+	for name, field := range tests {
+		if got := fieldName(name); got != field {
+			t.Errorf("fieldName(%q) = %q, expected %q", name, got, field)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		err    string
+	}{
+		{
+			name:   "variadic",
+			source: "// Sum sums.\n//\n//goreact:command\nfunc Sum(values ...int) int { return 0 }",
+			err:    "variadic",
+		},
+		{
+			name:   "unnamed parameter",
+			source: "// Sum sums.\n//\n//goreact:command\nfunc Sum(int, int) int { return 0 }",
+			err:    "must be named",
+		},
+		{
+			name:   "too many results",
+			source: "// Sum sums.\n//\n//goreact:command\nfunc Sum(a, b int) (int, int, error) { return 0, 0, nil }",
+			err:    "the results must be",
+		},
+		{
+			name:   "unexported",
+			source: "// sum sums.\n//\n//goreact:command\nfunc sum(a, b int) int { return 0 }",
+			err:    "must be an exported function",
+		},
+		{
+			name:   "no description",
+			source: "//goreact:command\nfunc Sum(a, b int) int { return 0 }",
+			err:    "no doc comment",
+		},
+		{
+			name:   "unknown type",
+			source: "// Sum sums.\n//\n//goreact:command\nfunc Sum(a, b Number) int { return 0 }",
+			err:    "is invalid",
+		},
+		{
+			name:   "no command",
+			source: "// Sum sums.\nfunc Sum(a, b int) int { return 0 }",
+			err:    "no function with //goreact:command",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			source := "package sum\n\n" + test.source + "\n"
+			if err := os.WriteFile(filepath.Join(dir, "sum.go"), []byte(source), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := generateSource(dir, "goreact_commands.go", "Commands")
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("expected an error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// Package example contains functions with the goreact:command
+// directive for the golden file test of goreact-gen.
+package example
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/dgruber/goreact/cmd/goreact-gen/testdata/example/geo/v2"
+	"github.com/dgruber/goreact/cmd/goreact-gen/testdata/example/units.v3"
+)
+
+//go:generate go run github.com/dgruber/goreact/cmd/goreact-gen
+
+type ID int
+
+type Options struct {
+	Query string `json:"query" desc:"the search term"`
+	Limit int    `json:"limit,omitempty" desc:"maximum amount of results"`
+}
+
+// Filter has a struct as underlying type.
+type Filter Options
+
+// Search searches the documents.
+//
+//goreact:command
+func Search(ctx context.Context, options Options) ([]string, error) {
+	return []string{options.Query}, nil
+}
+
+// Wait waits for the duration.
+//
+//goreact:command
+func Wait(ctx context.Context, duration time.Duration) error {
+	select {
+	case <-time.After(duration):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Lookup returns the document with the ID.
+//
+//goreact:command lookup_document
+func Lookup(id ID) string {
+	return fmt.Sprintf("document %d", id)
+}
+
+// Distance returns the distance between two points.
+//
+//goreact:command
+func Distance(from, to geo.Point, unit units.Unit) (float64, error) {
+	if unit != units.Kilometers {
+		return 0, fmt.Errorf("unsupported unit %s", unit)
+	}
+	return 111 * math.Hypot(to.Lat-from.Lat, to.Lon-from.Lon), nil
+}
+
+// Count counts the documents matching the filter.
+//
+//goreact:command
+func Count(filter Filter) int {
+	return filter.Limit
+}
+
+// Reset removes all documents.
+//
+//goreact:command
+func Reset() {}
+
+// commands uses the generated function.
+var commands = Commands
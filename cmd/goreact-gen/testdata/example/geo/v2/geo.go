@@ -0,0 +1,8 @@
+// Package geo is imported by the example package with a major version
+// suffix in its path.
+package geo
+
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
@@ -0,0 +1,64 @@
+// Code generated by goreact-gen. DO NOT EDIT.
+
+package example
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dgruber/goreact"
+	geo "github.com/dgruber/goreact/cmd/goreact-gen/testdata/example/geo/v2"
+	units "github.com/dgruber/goreact/cmd/goreact-gen/testdata/example/units.v3"
+)
+
+type distanceArgs struct {
+	From geo.Point  `json:"from" desc:"from"`
+	To   geo.Point  `json:"to" desc:"to"`
+	Unit units.Unit `json:"unit" desc:"unit"`
+}
+
+type lookupArgs struct {
+	ID ID `json:"id" desc:"id"`
+}
+
+type resetArgs struct{}
+
+type waitArgs struct {
+	Duration string `json:"duration" desc:"duration like 1m30s"`
+}
+
+// Commands returns the commands of the functions with the //goreact:command directive.
+func Commands() map[string]goreact.Command {
+	return goreact.Commands(
+		goreact.NewCommand("count", "Count counts the documents matching the filter.",
+			func(ctx context.Context, in Filter) (any, error) {
+				return Count(in), nil
+			}),
+		goreact.NewCommand("distance", "Distance returns the distance between two points.",
+			func(ctx context.Context, in distanceArgs) (any, error) {
+				return Distance(in.From, in.To, in.Unit)
+			}),
+		goreact.NewCommand("lookup_document", "Lookup returns the document with the ID.",
+			func(ctx context.Context, in lookupArgs) (any, error) {
+				return Lookup(in.ID), nil
+			}),
+		goreact.NewCommand("reset", "Reset removes all documents.",
+			func(ctx context.Context, in resetArgs) (any, error) {
+				Reset()
+				return "OK", nil
+			}),
+		goreact.NewCommand("search", "Search searches the documents.",
+			func(ctx context.Context, in Options) (any, error) {
+				return Search(ctx, in)
+			}),
+		goreact.NewCommand("wait", "Wait waits for the duration.",
+			func(ctx context.Context, in waitArgs) (any, error) {
+				duration, err := time.ParseDuration(in.Duration)
+				if err != nil {
+					return nil, fmt.Errorf("%w: duration: %v", goreact.ErrInvalidArguments, err)
+				}
+				return "OK", Wait(ctx, duration)
+			}),
+	)
+}
@@ -0,0 +1,10 @@
+// Package units is imported by the example package with a gopkg.in
+// style version in its path.
+package units
+
+type Unit string
+
+const (
+	Kilometers Unit = "km"
+	Miles      Unit = "mi"
+)
@@ -0,0 +1,201 @@
+package goreact
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ErrInvalidArguments is wrapped by the errors of the functions of
+// NewCommand for arguments they cannot use. Such an error is sent back
+// to the LLM as observation so that it can correct the arguments,
+// other errors stop answering the question.
+var ErrInvalidArguments = errors.New("invalid arguments")
+
+// NewCommand creates a command from a Go function. The parameters are
+// derived from the exported fields of the struct In:
+//
+//	type SearchArgs struct {
+//		Query  string `json:"query" desc:"the search term"`
+//		Limit  int    `json:"limit,omitempty" desc:"maximum amount of results"`
+//		Region string `json:"region,omitempty" enum:"us,eu"`
+//	}
+//
+// Fields with omitempty or of pointer type are optional. Struct fields
+// are objects with the parameters of their fields. time.Time
+// fields are RFC 3339 strings and []byte fields base64 strings. The
+// arguments of the LLM are unmarshalled into In and the result is
+// marshalled to JSON as observation; a string result is used as it is.
+// Arguments which cannot be unmarshalled, like 300 for an uint8, and
+// errors of fn wrapping ErrInvalidArguments are sent back to the LLM to
+// correct them. When In is a string the command
+// takes the argument as plain text. NewCommand panics when In is
+// neither a struct nor a string.
+func NewCommand[In, Out any](name, description string, fn func(ctx context.Context, in In) (Out, error)) Command {
+	command := Command{Name: name, Description: description}
+	typ := reflect.TypeFor[In]()
+	if typ.Kind() == reflect.String {
+		command.ContextFunc = func(ctx context.Context, argument string) (string, error) {
+			var in In
+			reflect.ValueOf(&in).Elem().SetString(argument)
+			return call(ctx, name, fn, in)
+		}
+		return command
+	}
+	if typ.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("goreact: input of command %s must be a struct or a string, not %s", name, typ))
+	}
+	command.Parameters = parameters(typ)
+	command.TypedFunc = func(ctx context.Context, args Arguments) (string, error) {
+		data, err := json.Marshal(args)
+		if err != nil {
+			return "", err
+		}
+		var in In
+		if err := json.Unmarshal(data, &in); err != nil {
+			return invalidArguments(name, fmt.Errorf("%w: %v", ErrInvalidArguments, err))
+		}
+		return call(ctx, name, fn, in)
+	}
+	return command
+}
+
+func call[In, Out any](ctx context.Context, name string, fn func(ctx context.Context, in In) (Out, error), in In) (string, error) {
+	out, err := fn(ctx, in)
+	if errors.Is(err, ErrInvalidArguments) {
+		return invalidArguments(name, err)
+	}
+	if err != nil {
+		return "", err
+	}
+	return observation(out)
+}
+
+// invalidArguments returns the observation which lets the LLM correct
+// the arguments together with err.
+func invalidArguments(name string, err error) (string, error) {
+	return fmt.Sprintf("The arguments of %s are invalid: %v", name, err), err
+}
+
+// Commands returns the commands by their name as expected by NewReact.
+func Commands(commands ...Command) map[string]Command {
+	byName := make(map[string]Command, len(commands))
+	for _, command := range commands {
+		byName[command.Name] = command
+	}
+	return byName
+}
+
+func observation(out any) (string, error) {
+	if s, ok := out.(string); ok {
+		return s, nil
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		return "", fmt.Errorf("unable to encode result: %w", err)
+	}
+	return string(data), nil
+}
+
+// parameters derives the parameters from the fields of a struct.
+func parameters(typ reflect.Type) []Parameter {
+	return structParameters(typ, map[reflect.Type]bool{})
+}
+
+// structParameters derives the parameters of the struct and of its
+// struct fields. A recursive struct is an object without properties
+// at the second level.
+func structParameters(typ reflect.Type, seen map[reflect.Type]bool) []Parameter {
+	seen[typ] = true
+	defer delete(seen, typ)
+	var parameters []Parameter
+	for _, field := range reflect.VisibleFields(typ) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && options == "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fieldType := field.Type
+		optional := strings.Contains(options, "omitempty")
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+			optional = true
+		}
+		parameter := Parameter{
+			Name:        name,
+			Type:        parameterType(fieldType),
+			Description: describeFormat(field.Tag.Get("desc"), fieldType),
+			Required:    !optional,
+		}
+		object := fieldType
+		if parameter.Type == TypeArray {
+			object = fieldType.Elem()
+			if object.Kind() == reflect.Pointer {
+				object = object.Elem()
+			}
+			parameter.Items = parameterType(object)
+		}
+		if parameterType(object) == TypeObject && object.Kind() == reflect.Struct && !seen[object] {
+			parameter.Properties = structParameters(object, seen)
+		}
+		if enum := field.Tag.Get("enum"); enum != "" {
+			for _, value := range strings.Split(enum, ",") {
+				parameter.Enum = append(parameter.Enum, strings.TrimSpace(value))
+			}
+		}
+		parameters = append(parameters, parameter)
+	}
+	return parameters
+}
+
+var (
+	timeType  = reflect.TypeFor[time.Time]()
+	bytesType = reflect.TypeFor[[]byte]()
+)
+
+func parameterType(typ reflect.Type) ParameterType {
+	if typ == timeType || typ == bytesType {
+		return TypeString
+	}
+	switch typ.Kind() {
+	case reflect.String:
+		return TypeString
+	case reflect.Bool:
+		return TypeBoolean
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return TypeInteger
+	case reflect.Float32, reflect.Float64:
+		return TypeNumber
+	case reflect.Slice, reflect.Array:
+		return TypeArray
+	}
+	return TypeObject
+}
+
+// describeFormat adds the format of time.Time and []byte fields, which
+// are strings in JSON, to the description.
+func describeFormat(description string, typ reflect.Type) string {
+	var format string
+	switch typ {
+	case timeType:
+		format = "RFC 3339 time"
+	case bytesType:
+		format = "base64 encoded"
+	default:
+		return description
+	}
+	if description == "" {
+		return format
+	}
+	return description + " (" + format + ")"
+}
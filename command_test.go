@@ -0,0 +1,208 @@
+package goreact
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+type scaleArgs struct {
+	Level    uint8     `json:"level" desc:"the level"`
+	Unit     string    `json:"unit,omitempty" enum:"m, km"`
+	Tags     []string  `json:"tags,omitempty"`
+	At       time.Time `json:"at,omitempty"`
+	Data     []byte    `json:"data,omitempty" desc:"raw data"`
+	Factor   *float64  `json:"factor"`
+	Internal string    `json:"-"`
+	hidden   string
+}
+
+func scale(ctx context.Context, in scaleArgs) (map[string]any, error) {
+	if in.Level == 0 {
+		return nil, errors.New("failed")
+	}
+	return map[string]any{"level": in.Level, "at": in.At.Year(), "data": string(in.Data)}, nil
+}
+
+func TestNewCommandParameters(t *testing.T) {
+	command := NewCommand("scale", "scales", scale)
+	want := []Parameter{
+		{Name: "level", Type: TypeInteger, Description: "the level", Required: true},
+		{Name: "unit", Type: TypeString, Enum: []string{"m", "km"}},
+		{Name: "tags", Type: TypeArray, Items: TypeString},
+		{Name: "at", Type: TypeString, Description: "RFC 3339 time"},
+		{Name: "data", Type: TypeString, Description: "raw data (base64 encoded)"},
+		{Name: "factor", Type: TypeNumber},
+	}
+	if fmt.Sprint(command.Parameters) != fmt.Sprint(want) {
+		t.Errorf("expected parameters\n%v, got\n%v", want, command.Parameters)
+	}
+	if command.Name != "scale" || command.Description != "scales" || !command.typed() {
+		t.Errorf("unexpected command %+v", command)
+	}
+}
+
+func TestNewCommandCall(t *testing.T) {
+	command := NewCommand("scale", "scales", scale)
+	tests := []struct {
+		name        string
+		argument    string
+		observation string
+		err         bool
+	}{
+		{"valid", `{"level": 3, "at": "2024-05-01T10:00:00Z", "data": "aGk="}`,
+			`{"at":2024,"data":"hi","level":3}`, false},
+		{"out of range", `{"level": 300}`, "The arguments of scale are invalid", true},
+		{"invalid time", `{"level": 3, "at": "yesterday"}`, "The arguments of scale are invalid", true},
+		{"error of the function", `{"level": 0}`, "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args, err := command.arguments(test.argument)
+			if err != nil {
+				t.Fatal(err)
+			}
+			observation, err := command.call(context.Background(), test.argument, args)
+			if (err != nil) != test.err || !strings.HasPrefix(observation, test.observation) ||
+				(test.observation == "" && observation != "") {
+				t.Errorf("unexpected observation %q, %v", observation, err)
+			}
+		})
+	}
+}
+
+func TestNewCommandInvalidArgumentsContinue(t *testing.T) {
+	var levels []uint8
+	command := NewCommand("scale", "scales", func(ctx context.Context, in scaleArgs) (string, error) {
+		levels = append(levels, in.Level)
+		return "scaled", nil
+	})
+	llm := &scriptedProvider{responses: []string{
+		`ACTION: scale {"level": 300}`,
+		`ACTION: scale {"level": 30}`,
+		"ANSWER: scaled",
+	}}
+	r, err := NewReact(llm, Commands(command))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := r.QuestionResult(context.Background(), "Scale it")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Answer != "scaled" || fmt.Sprint(levels) != "[30]" || result.Steps[0].Err == nil {
+		t.Errorf("unexpected result %+v with levels %v", result, levels)
+	}
+}
+
+func TestNewCommandString(t *testing.T) {
+	type query string
+	command := NewCommand("echo", "echoes", func(ctx context.Context, in query) (query, error) {
+		return in + "!", nil
+	})
+	if command.typed() {
+		t.Fatal("expected a command with a plain text argument")
+	}
+	observation, err := command.call(context.Background(), "hi", nil)
+	// results which are no string are encoded as JSON
+	if want, _ := json.Marshal("hi!"); err != nil || observation != string(want) {
+		t.Errorf("unexpected observation %q, %v", observation, err)
+	}
+}
+
+func TestNewCommandPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for an int input")
+		}
+	}()
+	NewCommand("count", "counts", func(ctx context.Context, in int) (string, error) {
+		return "", nil
+	})
+}
+
+type routeArgs struct {
+	From  point   `json:"from"`
+	Stops []point `json:"stops,omitempty"`
+	Via   *node   `json:"via,omitempty"`
+}
+
+type point struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// node is recursive.
+type node struct {
+	Name string `json:"name"`
+	Next *node  `json:"next,omitempty"`
+}
+
+func TestNewCommandNested(t *testing.T) {
+	command := NewCommand("route", "routes", func(ctx context.Context, in routeArgs) (string, error) {
+		return fmt.Sprint(in.From.Lat, len(in.Stops)), nil
+	})
+	coordinates := []Parameter{
+		{Name: "lat", Type: TypeNumber, Required: true},
+		{Name: "lon", Type: TypeNumber, Required: true},
+	}
+	want := []Parameter{
+		{Name: "from", Type: TypeObject, Required: true, Properties: coordinates},
+		{Name: "stops", Type: TypeArray, Items: TypeObject, Properties: coordinates},
+		{Name: "via", Type: TypeObject, Properties: []Parameter{
+			{Name: "name", Type: TypeString, Required: true},
+			{Name: "next", Type: TypeObject},
+		}},
+	}
+	if fmt.Sprint(command.Parameters) != fmt.Sprint(want) {
+		t.Errorf("expected parameters\n%v, got\n%v", want, command.Parameters)
+	}
+
+	schema, err := json.Marshal(command.schema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, nested := range []string{
+		`"from":{"properties":{"lat":{"type":"number"},"lon":{"type":"number"}},"required":["lat","lon"],"type":"object"}`,
+		`"items":{"properties":{"lat":{"type":"number"},"lon":{"type":"number"}},"required":["lat","lon"],"type":"object"}`,
+	} {
+		if !strings.Contains(string(schema), nested) {
+			t.Errorf("expected %s in the schema %s", nested, schema)
+		}
+	}
+	if signature := command.signature(); !strings.Contains(signature, `"from": {"lat": number, "lon": number}`) {
+		t.Errorf("unexpected signature %s", signature)
+	}
+
+	tests := []struct {
+		argument string
+		err      string
+	}{
+		{`{"from": {"lat": 1, "lon": 2}, "stops": [{"lat": 3, "lon": 4}]}`, ""},
+		{`{"from": {"lat": 1}}`, `the parameter "from" is invalid: the required parameter "lon" is missing`},
+		{`{"from": {"lat": "north", "lon": 2}}`, `the parameter "from" is invalid`},
+		{`{"from": {"lat": 1, "lon": 2}, "stops": [{"lat": 3, "height": 4}]}`, `unknown parameter "height"`},
+		// objects without properties accept any fields
+		{`{"from": {"lat": 1, "lon": 2}, "via": {"name": "a", "next": {"name": "b", "x": 1}}}`, ""},
+	}
+	for _, test := range tests {
+		_, err := command.arguments(test.argument)
+		if (err == nil) != (test.err == "") || (err != nil && !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("%s: expected error %q, got %v", test.argument, test.err, err)
+		}
+	}
+}
+
+func TestNewCommandErrInvalidArguments(t *testing.T) {
+	command := NewCommand("scale", "scales", func(ctx context.Context, in scaleArgs) (string, error) {
+		return "", fmt.Errorf("%w: level %d is too high", ErrInvalidArguments, in.Level)
+	})
+	observation, err := command.TypedFunc(context.Background(), Arguments{"level": 30})
+	if !errors.Is(err, ErrInvalidArguments) || observation != "The arguments of scale are invalid: invalid arguments: level 30 is too high" {
+		t.Errorf("unexpected observation %q, %v", observation, err)
+	}
+}
@@ -0,0 +1,176 @@
+package goreact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+var (
+	// ErrOverloaded is matched (errors.Is) by provider errors when the
+	// API is temporarily overloaded and the request can be retried
+	// later.
+	ErrOverloaded = errors.New("LLM API overloaded")
+	// ErrRateLimited is matched (errors.Is) by provider errors when
+	// the rate limit of the API is exceeded.
+	ErrRateLimited = errors.New("LLM API rate limit exceeded")
+)
+
+// ErrorClass tells whether and why a failed LLM request can be
+// retried.
+type ErrorClass int
+
+const (
+	// ErrorFatal errors fail again when the request is retried.
+	ErrorFatal ErrorClass = iota
+	// ErrorRateLimit errors are caused by exceeding the rate limit.
+	ErrorRateLimit
+	// ErrorOverload errors are caused by an overloaded or temporarily
+	// unavailable API.
+	ErrorOverload
+	// ErrorTimeout errors are caused by a request which took too long.
+	ErrorTimeout
+)
+
+func (c ErrorClass) String() string {
+	switch c {
+	case ErrorRateLimit:
+		return "rate limit"
+	case ErrorOverload:
+		return "overload"
+	case ErrorTimeout:
+		return "timeout"
+	}
+	return "fatal"
+}
+
+// Transient returns true when retrying the request might succeed.
+func (c ErrorClass) Transient() bool {
+	return c != ErrorFatal
+}
+
+// ClassifyError returns the class of an error returned by a provider.
+// Cancelled contexts are always fatal.
+func ClassifyError(err error) ErrorClass {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return ErrorFatal
+	}
+	if errors.Is(err, ErrRateLimited) {
+		return ErrorRateLimit
+	}
+	if errors.Is(err, ErrOverloaded) {
+		return ErrorOverload
+	}
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		return classifyStatus(providerErr.StatusCode)
+	}
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		if class := classifyStatus(apiErr.HTTPStatusCode); class != ErrorFatal {
+			return class
+		}
+	}
+	var requestErr *openai.RequestError
+	if errors.As(err, &requestErr) {
+		if class := classifyStatus(requestErr.HTTPStatusCode); class != ErrorFatal {
+			return class
+		}
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorTimeout
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorTimeout
+	}
+	if strings.Contains(err.Error(), "currently overloaded") {
+		return ErrorOverload
+	}
+	return ErrorFatal
+}
+
+func classifyStatus(status int) ErrorClass {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return ErrorRateLimit
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		return ErrorTimeout
+	case status == 529 || status >= 500:
+		return ErrorOverload
+	}
+	return ErrorFatal
+}
+
+// retryAfter returns the delay requested by the API or 0.
+func retryAfter(err error) time.Duration {
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		return providerErr.RetryAfter
+	}
+	return 0
+}
+
+// parseRetryAfter parses the Retry-After header which is either the
+// amount of seconds or an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// ProviderError is an error returned by the HTTP API of an LLM
+// provider.
+type ProviderError struct {
+	Provider   string
+	StatusCode int
+	// Type is the error type reported by the API, like
+	// overloaded_error or rate_limit_error.
+	Type    string
+	Message string
+	// RetryAfter is the delay requested by the API before the next
+	// request (Retry-After header). It is 0 when not set.
+	RetryAfter time.Duration
+	// Err is the error of the client library, like *openai.APIError.
+	Err error
+}
+
+func (e *ProviderError) Error() string {
+	if e.Type != "" {
+		return fmt.Sprintf("%s request failed with status %d (%s): %s",
+			e.Provider, e.StatusCode, e.Type, e.Message)
+	}
+	return fmt.Sprintf("%s request failed with status %d: %s",
+		e.Provider, e.StatusCode, e.Message)
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ProviderError) Is(target error) bool {
+	switch target {
+	case ErrOverloaded:
+		return e.Type == "overloaded_error" || e.StatusCode == 529 ||
+			e.StatusCode == http.StatusServiceUnavailable
+	case ErrRateLimited:
+		return e.Type == "rate_limit_error" || e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
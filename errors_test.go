@@ -0,0 +1,91 @@
+package goreact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+var _ net.Error = timeoutError{}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{"nil", nil, ErrorFatal},
+		{"canceled", context.Canceled, ErrorFatal},
+		{"wrapped canceled", fmt.Errorf("request: %w", context.Canceled), ErrorFatal},
+		{"deadline", context.DeadlineExceeded, ErrorTimeout},
+		{"net timeout", &net.OpError{Op: "read", Err: timeoutError{}}, ErrorTimeout},
+		{"rate limited", ErrRateLimited, ErrorRateLimit},
+		{"overloaded", fmt.Errorf("chat: %w", ErrOverloaded), ErrorOverload},
+		{"provider 429", &ProviderError{StatusCode: http.StatusTooManyRequests}, ErrorRateLimit},
+		{"provider 529", &ProviderError{StatusCode: 529}, ErrorOverload},
+		{"provider 500", &ProviderError{StatusCode: http.StatusInternalServerError}, ErrorOverload},
+		{"provider 504", &ProviderError{StatusCode: http.StatusGatewayTimeout}, ErrorTimeout},
+		{"provider 400", &ProviderError{StatusCode: http.StatusBadRequest}, ErrorFatal},
+		{"provider 401", &ProviderError{StatusCode: http.StatusUnauthorized}, ErrorFatal},
+		{"overloaded type", &ProviderError{StatusCode: http.StatusBadRequest, Type: "overloaded_error"}, ErrorOverload},
+		{"openai 429", &openai.APIError{HTTPStatusCode: http.StatusTooManyRequests}, ErrorRateLimit},
+		{"openai 503", &openai.RequestError{HTTPStatusCode: http.StatusServiceUnavailable}, ErrorOverload},
+		{"openai 400", &openai.APIError{HTTPStatusCode: http.StatusBadRequest}, ErrorFatal},
+		{"overloaded message", errors.New("the server is currently overloaded"), ErrorOverload},
+		{"other", errors.New("invalid api key"), ErrorFatal},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ClassifyError(test.err); got != test.want {
+				t.Errorf("ClassifyError(%v) = %v, expected %v", test.err, got, test.want)
+			}
+			if got := ClassifyError(test.err).Transient(); got != (test.want != ErrorFatal) {
+				t.Errorf("Transient() = %v", got)
+			}
+		})
+	}
+}
+
+func TestProviderErrorUnwrap(t *testing.T) {
+	apiErr := &openai.APIError{HTTPStatusCode: http.StatusTooManyRequests, Message: "slow down"}
+	err := fmt.Errorf("chat: %w", &ProviderError{Provider: "openai", StatusCode: 429, Err: apiErr})
+	var got *openai.APIError
+	if !errors.As(err, &got) || got != apiErr {
+		t.Errorf("expected the *openai.APIError, got %v", got)
+	}
+	if !errors.Is(err, ErrRateLimited) {
+		t.Error("expected ErrRateLimited")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	tests := []struct {
+		value    string
+		min, max time.Duration
+	}{
+		{value: "", min: 0, max: 0},
+		{value: "7", min: 7 * time.Second, max: 7 * time.Second},
+		{value: date, min: 50 * time.Second, max: time.Minute},
+		{value: "Mon, 02 Jan 2006 15:04:05 GMT", min: 0, max: 0},
+		{value: "soon", min: 0, max: 0},
+	}
+	for _, test := range tests {
+		if d := parseRetryAfter(test.value); d < test.min || d > test.max {
+			t.Errorf("parseRetryAfter(%q) = %v, expected between %v and %v",
+				test.value, d, test.min, test.max)
+		}
+	}
+}
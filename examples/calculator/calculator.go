@@ -1,13 +1,34 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
 
 	"github.com/dgruber/goreact"
 	calculator "github.com/mnogu/go-calculator"
 )
 
+const question = "What is the square root of 10? What is PI? What is the sum of both numbers?"
+
+type CalculateArgs struct {
+	Expression string `json:"expression" desc:"the expression like 180*atan2(log(e), log10(10))/pi"`
+}
+
+func commands() map[string]goreact.Command {
+	return goreact.Commands(
+		goreact.NewCommand("calculate", "Calculate the answer to a math problem.",
+			func(ctx context.Context, args CalculateArgs) (string, error) {
+				result, err := calculator.Calculate(args.Expression)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%f", result), nil
+			}),
+	)
+}
+
 func main() {
 
 	openaiProvider, err := goreact.NewOpenAIProvider(os.Getenv("OPENAI_API_KEY"))
@@ -16,28 +37,24 @@ func main() {
 		os.Exit(1)
 	}
 
-	commands := map[string]goreact.Command{
-		"calculate": {
-			Name:        "calculate",
-			Argument:    "expression",
-			Description: "Calculate the answer to a math problem. The expression is like: 180*atan2(log(e), log10(10))/pi",
-			Func: func(question string) (string, error) {
-				result, err := calculator.Calculate(question)
-				if err != nil {
-					return "", err
-				}
-				return fmt.Sprintf("%f", result), nil
-			},
-		},
+	var llm goreact.LLMProvider = openaiProvider
+	if cassette := os.Getenv("GOREACT_CASSETTE"); cassette != "" {
+		// replay the recorded LLM responses (or record them on the first run)
+		llm, err = goreact.NewCassetteProvider(llm, cassette)
+		if err != nil {
+			fmt.Printf("Failed to open cassette: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
-	reactor, err := goreact.NewReact(openaiProvider, commands)
+	reactor, err := goreact.NewReact(llm, commands())
 	if err != nil {
 		fmt.Printf("Failed to create Reactor: %v\n", err)
 		os.Exit(1)
 	}
+	reactor.WithLogger(slog.New(slog.NewTextHandler(os.Stdout, nil)))
 
-	answer, err := reactor.Question("What is the square root of 10? What is PI? What is the sum of both numbers?")
+	answer, err := reactor.Question(question)
 	if err != nil {
 		fmt.Printf("Failed to get answer: %v\n", err)
 		os.Exit(1)
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dgruber/goreact"
+	"github.com/dgruber/goreact/goreacttest"
+)
+
+// TestCalculatorCassette replays the synthetic cassette
+// testdata/calculator.json. Its responses were written by hand, not recorded
+// from an LLM, for the requests of the current prompts.
+func TestCalculatorCassette(t *testing.T) {
+	llm, err := goreact.NewReplayProvider("testdata/calculator.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reactor, err := goreact.NewReact(llm, commands())
+	if err != nil {
+		t.Fatal(err)
+	}
+	reactor.WithClock(func() time.Time { return time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC) })
+
+	result, err := reactor.QuestionResult(context.Background(), question)
+	if err != nil {
+		t.Fatal(err)
+	}
+	goreacttest.AssertCommands(t, result, "calculate", "calculate", "calculate")
+	goreacttest.AssertObservation(t, result, 0, "3.162278")
+	goreacttest.AssertObservation(t, result, 1, "3.141593")
+	goreacttest.AssertObservation(t, result, 2, "6.303871")
+	goreacttest.AssertAnswer(t, result, "6.303871")
+	if remaining := llm.Remaining(); remaining != 0 {
+		t.Errorf("%d responses of the cassette were not replayed", remaining)
+	}
+}
@@ -1,78 +1,95 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
 
 	"github.com/dgruber/goreact"
 )
 
-func main() {
-
-	openaiProvider, err := goreact.NewOpenAIProvider(os.Getenv("OPENAI_API_KEY"))
-	if err != nil {
-		fmt.Printf("Failed to create OpenAIProvider: %v\n", err)
-		os.Exit(1)
-	}
+const question = "How many coins are in the rooms?"
 
-	type Room struct {
-		name      string
-		hasCoin   bool
-		neighbors map[string]Room
-	}
+type Room struct {
+	name      string
+	hasCoin   bool
+	neighbors map[string]Room
+}
 
-	floorPlan := Room{
-		"hallway",
-		false,
-		map[string]Room{
-			"west": {
-				"kitchen",
-				false,
-				nil,
-			},
-			"north": {
-				"bedroom",
-				false,
-				nil,
-			},
-			"east": {
-				"bathroom",
-				false,
-				nil,
-			},
-			"south": {
-				"living room",
-				true,
-				nil,
-			},
+var floorPlan = Room{
+	"hallway",
+	false,
+	map[string]Room{
+		"west": {
+			"kitchen",
+			false,
+			nil,
 		},
-	}
+		"north": {
+			"bedroom",
+			false,
+			nil,
+		},
+		"east": {
+			"bathroom",
+			false,
+			nil,
+		},
+		"south": {
+			"living room",
+			true,
+			nil,
+		},
+	},
+}
 
-	commands := map[string]goreact.Command{
-		"look": {
-			Name:        "look",
-			Argument:    "direction",
-			Description: "Looks into a room which is north, south, east, or west. The result is a description of the room and tells if it contains a coin.",
-			Func: func(direction string) (string, error) {
-				room, ok := floorPlan.neighbors[direction]
+type LookArgs struct {
+	Direction string `json:"direction" enum:"north,south,east,west"`
+}
+
+func commands() map[string]goreact.Command {
+	return goreact.Commands(
+		goreact.NewCommand("look", "Looks into a room which is north, south, east, or west. The result is a description of the room and tells if it contains a coin.",
+			func(ctx context.Context, args LookArgs) (string, error) {
+				room, ok := floorPlan.neighbors[args.Direction]
 				if !ok {
 					return "You see a wall.", nil
 				}
 				if room.hasCoin {
 					return "You found a coin in " + room.name, nil
 				}
-				return "There is nothing " + direction + " in " + room.name, nil
-			},
-		},
+				return "There is nothing " + args.Direction + " in " + room.name, nil
+			}),
+	)
+}
+
+func main() {
+
+	openaiProvider, err := goreact.NewOpenAIProvider(os.Getenv("OPENAI_API_KEY"))
+	if err != nil {
+		fmt.Printf("Failed to create OpenAIProvider: %v\n", err)
+		os.Exit(1)
+	}
+
+	var llm goreact.LLMProvider = openaiProvider
+	if cassette := os.Getenv("GOREACT_CASSETTE"); cassette != "" {
+		// replay the recorded LLM responses (or record them on the first run)
+		llm, err = goreact.NewCassetteProvider(llm, cassette)
+		if err != nil {
+			fmt.Printf("Failed to open cassette: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
-	reactor, err := goreact.NewReact(openaiProvider, commands)
+	reactor, err := goreact.NewReact(llm, commands())
 	if err != nil {
 		fmt.Printf("Failed to create Reactor: %v\n", err)
 		os.Exit(1)
 	}
+	reactor.WithLogger(slog.New(slog.NewTextHandler(os.Stdout, nil)))
 
-	answer, err := reactor.Question("How many coins are in the rooms?")
+	answer, err := reactor.Question(question)
 	if err != nil {
 		fmt.Printf("Failed to get answer: %v\n", err)
 		os.Exit(1)
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dgruber/goreact"
+	"github.com/dgruber/goreact/goreacttest"
+)
+
+// TestRoomsCassette replays the synthetic cassette
+// testdata/rooms.json. Its responses were written by hand, not recorded
+// from an LLM, for the requests of the current prompts.
+func TestRoomsCassette(t *testing.T) {
+	llm, err := goreact.NewReplayProvider("testdata/rooms.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reactor, err := goreact.NewReact(llm, commands())
+	if err != nil {
+		t.Fatal(err)
+	}
+	reactor.WithClock(func() time.Time { return time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC) })
+
+	result, err := reactor.QuestionResult(context.Background(), question)
+	if err != nil {
+		t.Fatal(err)
+	}
+	goreacttest.AssertCommands(t, result, "look", "look", "look", "look")
+	goreacttest.AssertObservation(t, result, 3, "You found a coin in living room")
+	goreacttest.AssertAnswer(t, result, "one coin")
+	if remaining := llm.Remaining(); remaining != 0 {
+		t.Errorf("%d responses of the cassette were not replayed", remaining)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"log/slog"
 	"net/http"
 	"os"
 	"time"
@@ -15,6 +16,91 @@ import (
 	"jaytaylor.com/html2text"
 )
 
+// scrapePage returns the text of a web page.
+var scrapePage = func(ctx context.Context, address string) (string, error) {
+	// get the content of the web page
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+	if err != nil {
+		return err.Error(), nil
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		fmt.Printf("Failed to get web page %s: %v", address, err)
+		return err.Error(), nil
+	}
+	defer resp.Body.Close()
+
+	// convert HTML to text
+	text, err := html2text.FromReader(resp.Body, html2text.Options{TextOnly: true})
+	if err != nil {
+		fmt.Printf("Failed to convert HTML to text: %v", err)
+		return "", err
+	}
+	fmt.Printf("Scraped text with length %d\n", len(text))
+	// write text to file in the current directory
+	ioutil.WriteFile(fmt.Sprintf("./scrape-%s-%d.txt",
+		address, time.Now().Unix()), []byte(text), 0644)
+
+	return text, nil
+}
+
+// searchWeb returns the Google search results of a term.
+var searchWeb = func(ctx context.Context, term string) (string, error) {
+	var opts = googlesearch.SearchOptions{
+		CountryCode:    "de",
+		LanguageCode:   "en",
+		Limit:          5,
+		Start:          0,
+		OverLimit:      false,
+		FollowNextPage: false,
+		UserAgent:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/113.0.0.0 Safari/537.36"}
+	result, err := googlesearch.Search(ctx, term, opts)
+	if err != nil {
+		fmt.Printf("Search failed with error: %v\n", err)
+		return "search failed with error: " + err.Error(), nil
+	}
+	fmt.Printf("Search result: %v\n", result)
+	return fmt.Sprintf("%v", result), nil
+}
+
+// askUser returns the answer of the user to a question.
+var askUser = func(question string) string {
+	// get interactive answer from user
+	fmt.Printf("Please answer the question: %s\n", question)
+	reader := bufio.NewReader(os.Stdin)
+	text, _ := reader.ReadString('\n')
+	return text
+}
+
+type ScrapeArgs struct {
+	Address string `json:"address" desc:"the http address of the web page"`
+}
+
+type SearchArgs struct {
+	Term string `json:"term" desc:"the search term"`
+}
+
+type AskArgs struct {
+	Question string `json:"question" desc:"the question for the user"`
+}
+
+func commands() map[string]goreact.Command {
+	return goreact.Commands(
+		goreact.NewCommand("scrape", "Scrape reads the content of a web page given by the http address",
+			func(ctx context.Context, args ScrapeArgs) (string, error) {
+				return scrapePage(ctx, args.Address)
+			}),
+		goreact.NewCommand("search", "Search for a term on Google",
+			func(ctx context.Context, args SearchArgs) (string, error) {
+				return searchWeb(ctx, args.Term)
+			}),
+		goreact.NewCommand("ask", "Ask a question to the user for further clarification of the question",
+			func(ctx context.Context, args AskArgs) (string, error) {
+				return askUser(args.Question), nil
+			}),
+	)
+}
+
 func main() {
 
 	openaiProvider, err := goreact.NewOpenAIProvider(os.Getenv("OPENAI_API_KEY"))
@@ -25,75 +111,22 @@ func main() {
 
 	openaiProvider.WithModel("gpt-4o")
 
-	commands := map[string]goreact.Command{
-		"scrape": {
-			Name:        "scrape",
-			Argument:    "http address",
-			Description: "Scrape reads the content of a web page given by the http address",
-			Func: func(address string) (string, error) {
-				// get the content of the web page
-				resp, err := http.Get(address)
-				if err != nil {
-					fmt.Printf("Failed to get web page %s: %v", address, err)
-					return err.Error(), nil
-				}
-				defer resp.Body.Close()
-
-				// convert HTML to text
-				text, err := html2text.FromReader(resp.Body, html2text.Options{TextOnly: true})
-				if err != nil {
-					fmt.Printf("Failed to convert HTML to text: %v", err)
-					return "", err
-				}
-				fmt.Printf("Scraped text with length %d\n", len(text))
-				// write text to file in the current directory
-				ioutil.WriteFile(fmt.Sprintf("./scrape-%s-%d.txt",
-					address, time.Now().Unix()), []byte(text), 0644)
-
-				return text, nil
-			},
-		},
-		"search": {
-			Name:        "search",
-			Argument:    "search term",
-			Description: "Search for a term on Google",
-			Func: func(term string) (string, error) {
-				var opts = googlesearch.SearchOptions{
-					CountryCode:    "de",
-					LanguageCode:   "en",
-					Limit:          5,
-					Start:          0,
-					OverLimit:      false,
-					FollowNextPage: false,
-					UserAgent:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/113.0.0.0 Safari/537.36"}
-				result, err := googlesearch.Search(context.Background(), term, opts)
-				if err != nil {
-					fmt.Printf("Search failed with error: %v\n", err)
-					return "search failed with error: " + err.Error(), err
-				}
-				fmt.Printf("Search result: %v\n", result)
-				return fmt.Sprintf("%v", result), nil
-			},
-		},
-		"ask": {
-			Name:        "ask",
-			Argument:    "question",
-			Description: "Ask a question to the user for further clarification of the question",
-			Func: func(term string) (string, error) {
-				// get interactive answer from user
-				fmt.Printf("Please answer the question: %s\n", term)
-				reader := bufio.NewReader(os.Stdin)
-				text, _ := reader.ReadString('\n')
-				return text, nil
-			},
-		},
+	var llm goreact.LLMProvider = openaiProvider
+	if cassette := os.Getenv("GOREACT_CASSETTE"); cassette != "" {
+		// replay the recorded LLM responses (or record them on the first run)
+		llm, err = goreact.NewCassetteProvider(llm, cassette)
+		if err != nil {
+			fmt.Printf("Failed to open cassette: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
-	reactor, err := goreact.NewReact(openaiProvider, commands)
+	reactor, err := goreact.NewReact(llm, commands())
 	if err != nil {
 		fmt.Printf("Failed to create Reactor: %v\n", err)
 		os.Exit(1)
 	}
+	reactor.WithLogger(slog.New(slog.NewTextHandler(os.Stdout, nil)))
 
 	var question string
 	if len(os.Args) > 1 {
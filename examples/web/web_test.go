@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dgruber/goreact"
+	"github.com/dgruber/goreact/goreacttest"
+)
+
+const testQuestion = "Which programming language is goreact written in?"
+
+// TestWebCassette replays the synthetic cassette
+// testdata/web.json. Its responses were written by hand, not recorded
+// from an LLM, for the requests of the current prompts. Search and
+// scrape are replaced so that the test runs offline.
+func TestWebCassette(t *testing.T) {
+	searchWeb = func(ctx context.Context, term string) (string, error) {
+		return "[{1 https://github.com/dgruber/goreact goreact ReAct for Go}]", nil
+	}
+	scrapePage = func(ctx context.Context, address string) (string, error) {
+		return "goreact is an implementation of the ReAct pattern written in Go.", nil
+	}
+	askUser = func(question string) string {
+		t.Errorf("unexpected question to the user: %s", question)
+		return ""
+	}
+	llm, err := goreact.NewReplayProvider("testdata/web.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reactor, err := goreact.NewReact(llm, commands())
+	if err != nil {
+		t.Fatal(err)
+	}
+	reactor.WithClock(func() time.Time { return time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC) })
+
+	result, err := reactor.QuestionResult(context.Background(), testQuestion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	goreacttest.AssertCommands(t, result, "search", "scrape")
+	goreacttest.AssertArguments(t, result, `{"term": "goreact"}`, `{"address": "https://github.com/dgruber/goreact"}`)
+	goreacttest.AssertAnswer(t, result, "Go")
+	if remaining := llm.Remaining(); remaining != 0 {
+		t.Errorf("%d responses of the cassette were not replayed", remaining)
+	}
+}
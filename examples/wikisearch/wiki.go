@@ -1,13 +1,43 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
 
 	"github.com/dgruber/goreact"
 	gowiki "github.com/trietmn/go-wiki"
 )
 
+const question = "What is the capital of Germany? What is the capital of France"
+
+// pageContent returns the content of the Wikipedia page of a topic.
+var pageContent = func(topic string) (string, error) {
+	page, err := gowiki.GetPage(topic, -1, false, true)
+	if err != nil {
+		return "", err
+	}
+	return page.GetContent()
+}
+
+type WikisearchArgs struct {
+	Topic string `json:"topic" desc:"the topic to search for"`
+}
+
+func commands() map[string]goreact.Command {
+	return goreact.Commands(
+		goreact.NewCommand("wikisearch", "wikisearch searches Wikipedia for a topic",
+			func(ctx context.Context, args WikisearchArgs) (string, error) {
+				content, err := pageContent(args.Topic)
+				if err != nil {
+					return "Topic " + args.Topic + " not found in Wikipedia", nil
+				}
+				return content, nil
+			}),
+	)
+}
+
 func main() {
 
 	openaiProvider, err := goreact.NewOpenAIProvider(os.Getenv("OPENAI_API_KEY"))
@@ -16,33 +46,24 @@ func main() {
 		os.Exit(1)
 	}
 
-	commands := map[string]goreact.Command{
-		"wikisearch": {
-			Name:        "wikisearch",
-			Argument:    "topic",
-			Description: "wikisearch searches Wikipedia for a topic",
-			Func: func(topic string) (string, error) {
-				page, err := gowiki.GetPage(topic, -1, false, true)
-				if err != nil {
-					return "Topic " + topic + "not found in Wikipedia", err
-				}
-				// Get the content of the page
-				content, err := page.GetContent()
-				if err != nil {
-					return err.Error(), err
-				}
-				return content, nil
-			},
-		},
+	var llm goreact.LLMProvider = openaiProvider
+	if cassette := os.Getenv("GOREACT_CASSETTE"); cassette != "" {
+		// replay the recorded LLM responses (or record them on the first run)
+		llm, err = goreact.NewCassetteProvider(llm, cassette)
+		if err != nil {
+			fmt.Printf("Failed to open cassette: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
-	reactor, err := goreact.NewReact(openaiProvider, commands)
+	reactor, err := goreact.NewReact(llm, commands())
 	if err != nil {
 		fmt.Printf("Failed to create Reactor: %v\n", err)
 		os.Exit(1)
 	}
+	reactor.WithLogger(slog.New(slog.NewTextHandler(os.Stdout, nil)))
 
-	answer, err := reactor.Question("What is the capital of Germany? What is the capital of France")
+	answer, err := reactor.Question(question)
 	if err != nil {
 		fmt.Printf("Failed to get answer: %v\n", err)
 		os.Exit(1)
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dgruber/goreact"
+	"github.com/dgruber/goreact/goreacttest"
+)
+
+// pages replaces Wikipedia so that the test runs offline.
+var pages = map[string]string{
+	"Germany": "Germany is a country in Central Europe. Its capital and largest city is Berlin.",
+	"France":  "France is a country in Western Europe. Its capital and largest city is Paris.",
+}
+
+// TestWikisearchCassette replays the synthetic cassette
+// testdata/wikisearch.json. Its responses were written by hand, not recorded
+// from an LLM, for the requests of the current prompts.
+func TestWikisearchCassette(t *testing.T) {
+	pageContent = func(topic string) (string, error) {
+		content, ok := pages[topic]
+		if !ok {
+			return "", fmt.Errorf("page %s not found", topic)
+		}
+		return content, nil
+	}
+	llm, err := goreact.NewReplayProvider("testdata/wikisearch.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reactor, err := goreact.NewReact(llm, commands())
+	if err != nil {
+		t.Fatal(err)
+	}
+	reactor.WithClock(func() time.Time { return time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC) })
+
+	result, err := reactor.QuestionResult(context.Background(), question)
+	if err != nil {
+		t.Fatal(err)
+	}
+	goreacttest.AssertCommands(t, result, "wikisearch", "wikisearch")
+	goreacttest.AssertArguments(t, result, `{"topic": "Germany"}`, `{"topic": "France"}`)
+	goreacttest.AssertSummarizations(t, result, 0)
+	goreacttest.AssertAnswer(t, result, "Berlin")
+	goreacttest.AssertAnswer(t, result, "Paris")
+	if remaining := llm.Remaining(); remaining != 0 {
+		t.Errorf("%d responses of the cassette were not replayed", remaining)
+	}
+}
@@ -0,0 +1,180 @@
+package goreact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FailoverProvider sends requests to the first healthy provider of a
+// list of providers, like OpenAI followed by a local Ollama. When a
+// provider fails the next one is tried. After a number of consecutive
+// failures a provider is skipped (its circuit is open) until a cooldown
+// has passed.
+type FailoverProvider struct {
+	mu        sync.Mutex
+	providers []*failoverEntry
+	threshold int
+	cooldown  time.Duration
+	timeout   time.Duration
+	clock     func() time.Time
+}
+
+type failoverEntry struct {
+	provider  LLMProvider
+	failures  int
+	openUntil time.Time
+}
+
+// NewFailoverProvider tries the providers in the given order. By
+// default the circuit of a provider opens after 3 consecutive failures
+// for 30 seconds.
+func NewFailoverProvider(providers ...LLMProvider) *FailoverProvider {
+	f := &FailoverProvider{
+		threshold: 3,
+		cooldown:  30 * time.Second,
+		clock:     time.Now,
+	}
+	for _, provider := range providers {
+		f.providers = append(f.providers, &failoverEntry{provider: provider})
+	}
+	return f
+}
+
+// WithCircuitBreaker sets after how many consecutive failures a
+// provider is skipped and for how long.
+func (f *FailoverProvider) WithCircuitBreaker(threshold int, cooldown time.Duration) *FailoverProvider {
+	f.threshold = threshold
+	f.cooldown = cooldown
+	return f
+}
+
+// WithTimeout limits the time of each request to a single provider
+// so that a hanging provider fails over to the next one. 0 means no
+// timeout.
+func (f *FailoverProvider) WithTimeout(timeout time.Duration) *FailoverProvider {
+	f.timeout = timeout
+	return f
+}
+
+// Model returns the model of the provider which gets the next request.
+func (f *FailoverProvider) Model() string {
+	if len(f.providers) == 0 {
+		return ""
+	}
+	return modelOf(f.candidates()[0].provider)
+}
+
+func (f *FailoverProvider) Request(ctx context.Context, system, prompt string) (string, error) {
+	var response string
+	err := f.failover(ctx, func(ctx context.Context, provider LLMProvider) error {
+		var err error
+		response, err = provider.Request(ctx, system, prompt)
+		return err
+	})
+	return response, err
+}
+
+func (f *FailoverProvider) Chat(ctx context.Context, messages []Message) (string, error) {
+	var response string
+	err := f.failover(ctx, func(ctx context.Context, provider LLMProvider) error {
+		var err error
+		response, err = asChatProvider(provider).Chat(ctx, messages)
+		return err
+	})
+	return response, err
+}
+
+// RequestTools fails over between the providers which support tools.
+func (f *FailoverProvider) RequestTools(ctx context.Context, messages []Message, tools []Tool) (Message, error) {
+	var response Message
+	err := f.failover(ctx, func(ctx context.Context, provider LLMProvider) error {
+		toolProvider, ok := provider.(ToolProvider)
+		if !ok {
+			return ErrToolsNotSupported
+		}
+		var err error
+		response, err = toolProvider.RequestTools(ctx, messages, tools)
+		return err
+	})
+	return response, err
+}
+
+func (f *FailoverProvider) failover(ctx context.Context, request func(context.Context, LLMProvider) error) error {
+	if len(f.providers) == 0 {
+		return fmt.Errorf("no providers configured")
+	}
+	var errs []error
+	candidates := f.candidates()
+	for _, entry := range candidates {
+		err := f.try(ctx, entry, request)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		errs = append(errs, err)
+	}
+	err := errors.Join(errs...)
+	if allToolsNotSupported(errs) {
+		return fmt.Errorf("%w: %v", ErrToolsNotSupported, err)
+	}
+	return fmt.Errorf("all providers failed: %w", err)
+}
+
+func (f *FailoverProvider) try(ctx context.Context, entry *failoverEntry, request func(context.Context, LLMProvider) error) error {
+	if f.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.timeout)
+		defer cancel()
+	}
+	err := request(ctx, entry.provider)
+	if errors.Is(err, ErrToolsNotSupported) {
+		// not a failure of the provider
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		entry.failures = 0
+		return nil
+	}
+	entry.failures++
+	if f.threshold > 0 && entry.failures >= f.threshold {
+		entry.openUntil = f.clock().Add(f.cooldown)
+	}
+	return err
+}
+
+// candidates returns the providers with a closed circuit. When all
+// circuits are open all providers are returned so that the request
+// has a chance to succeed.
+func (f *FailoverProvider) candidates() []*failoverEntry {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := f.clock()
+	var candidates []*failoverEntry
+	for _, entry := range f.providers {
+		if now.Before(entry.openUntil) {
+			continue
+		}
+		candidates = append(candidates, entry)
+	}
+	if len(candidates) == 0 {
+		return f.providers
+	}
+	return candidates
+}
+
+func allToolsNotSupported(errs []error) bool {
+	for _, err := range errs {
+		if !errors.Is(err, ErrToolsNotSupported) {
+			return false
+		}
+	}
+	return len(errs) > 0
+}
@@ -0,0 +1,133 @@
+package goreact
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// modelProvider responds with its model or fails when down.
+type modelProvider struct {
+	model    string
+	down     bool
+	requests int
+}
+
+func (m *modelProvider) Model() string {
+	return m.model
+}
+
+func (m *modelProvider) Request(ctx context.Context, system, prompt string) (string, error) {
+	m.requests++
+	if m.down {
+		return "", &ProviderError{Provider: m.model, StatusCode: 529}
+	}
+	return m.model, nil
+}
+
+func TestFailoverProviderOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		down     []bool
+		response string
+		requests []int
+	}{
+		{"first", []bool{false, false, false}, "a", []int{1, 0, 0}},
+		{"second", []bool{true, false, false}, "b", []int{1, 1, 0}},
+		{"last", []bool{true, true, false}, "c", []int{1, 1, 1}},
+		{"none", []bool{true, true, true}, "", []int{1, 1, 1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var providers []*modelProvider
+			var llms []LLMProvider
+			for i, down := range test.down {
+				provider := &modelProvider{model: string(rune('a' + i)), down: down}
+				providers = append(providers, provider)
+				llms = append(llms, provider)
+			}
+			response, err := NewFailoverProvider(llms...).Request(context.Background(), "system", "prompt")
+			if response != test.response || (err != nil) != (test.response == "") {
+				t.Errorf("unexpected response %q, %v", response, err)
+			}
+			if err != nil && !strings.Contains(err.Error(), "all providers failed") {
+				t.Errorf("expected all errors, got %v", err)
+			}
+			for i, provider := range providers {
+				if provider.requests != test.requests[i] {
+					t.Errorf("expected %d requests to %s, got %d", test.requests[i], provider.model, provider.requests)
+				}
+			}
+		})
+	}
+}
+
+func TestFailoverProviderCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	primary := &modelProvider{model: "primary", down: true}
+	secondary := &modelProvider{model: "secondary"}
+	f := NewFailoverProvider(primary, secondary).WithCircuitBreaker(2, time.Minute)
+	f.clock = func() time.Time { return now }
+
+	request := func(model string, primaryRequests int) {
+		t.Helper()
+		response, err := f.Request(context.Background(), "system", "prompt")
+		if err != nil || response != model {
+			t.Fatalf("expected %s, got %q, %v", model, response, err)
+		}
+		if primary.requests != primaryRequests {
+			t.Fatalf("expected %d requests to the primary, got %d", primaryRequests, primary.requests)
+		}
+	}
+
+	// closed: the primary is tried until the threshold is reached
+	request("secondary", 1)
+	if f.Model() != "primary" {
+		t.Errorf("expected the primary model while the circuit is closed, got %s", f.Model())
+	}
+	request("secondary", 2)
+
+	// open: the primary is skipped
+	if f.Model() != "secondary" {
+		t.Errorf("expected the secondary model while the circuit is open, got %s", f.Model())
+	}
+	request("secondary", 2)
+
+	// half-open: after the cooldown a single failure opens it again
+	now = now.Add(time.Minute)
+	if f.Model() != "primary" {
+		t.Errorf("expected the primary model after the cooldown, got %s", f.Model())
+	}
+	request("secondary", 3)
+	request("secondary", 3)
+
+	// reset: a success closes the circuit and resets the failures
+	now = now.Add(time.Minute)
+	primary.down = false
+	request("primary", 4)
+	primary.down = true
+	request("secondary", 5)
+	request("secondary", 6)
+	request("secondary", 6)
+}
+
+func TestFailoverProviderAllCircuitsOpen(t *testing.T) {
+	primary := &modelProvider{model: "primary", down: true}
+	secondary := &modelProvider{model: "secondary", down: true}
+	f := NewFailoverProvider(primary, secondary).WithCircuitBreaker(1, time.Hour)
+	if _, err := f.Request(context.Background(), "system", "prompt"); err == nil {
+		t.Fatal("expected an error")
+	}
+	// all circuits are open, hence all providers get a chance
+	secondary.down = false
+	response, err := f.Request(context.Background(), "system", "prompt")
+	if err != nil || response != "secondary" || primary.requests != 2 {
+		t.Errorf("unexpected response %q, %v after %d requests to the primary", response, err, primary.requests)
+	}
+	var providerErr *ProviderError
+	if _, err := NewFailoverProvider(primary).Request(context.Background(), "system", "prompt"); !errors.As(err, &providerErr) {
+		t.Errorf("expected the error of the provider, got %v", err)
+	}
+}
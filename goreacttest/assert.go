@@ -0,0 +1,94 @@
+package goreacttest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dgruber/goreact"
+)
+
+// Commands returns the commands of all steps which executed a command.
+func Commands(result *goreact.Result) []string {
+	var commands []string
+	for _, step := range result.Steps {
+		if step.Command != "" {
+			commands = append(commands, step.Command)
+		}
+	}
+	return commands
+}
+
+// Arguments returns the arguments of all steps which executed a
+// command.
+func Arguments(result *goreact.Result) []string {
+	var arguments []string
+	for _, step := range result.Steps {
+		if step.Command != "" {
+			arguments = append(arguments, step.Argument)
+		}
+	}
+	return arguments
+}
+
+// AssertCommands fails the test when the executed commands differ
+// from the expected commands.
+func AssertCommands(t testing.TB, result *goreact.Result, expected ...string) {
+	t.Helper()
+	if actual := Commands(result); !equal(actual, expected) {
+		t.Errorf("expected commands %q but got %q", expected, actual)
+	}
+}
+
+// AssertArguments fails the test when the arguments of the executed
+// commands differ from the expected arguments.
+func AssertArguments(t testing.TB, result *goreact.Result, expected ...string) {
+	t.Helper()
+	if actual := Arguments(result); !equal(actual, expected) {
+		t.Errorf("expected arguments %q but got %q", expected, actual)
+	}
+}
+
+// AssertSummarizations fails the test when the amount of requests for
+// compressing observations differs from expected.
+func AssertSummarizations(t testing.TB, result *goreact.Result, expected int) {
+	t.Helper()
+	if result.SummarizationCalls != expected {
+		t.Errorf("expected %d summarization calls but got %d",
+			expected, result.SummarizationCalls)
+	}
+}
+
+// AssertAnswer fails the test when the answer does not contain
+// expected.
+func AssertAnswer(t testing.TB, result *goreact.Result, expected string) {
+	t.Helper()
+	if !strings.Contains(result.Answer, expected) {
+		t.Errorf("expected answer containing %q but got %q", expected, result.Answer)
+	}
+}
+
+// AssertObservation fails the test when the raw observation of the
+// step with the given index does not contain expected.
+func AssertObservation(t testing.TB, result *goreact.Result, step int, expected string) {
+	t.Helper()
+	if step < 0 {
+		t.Errorf("invalid step %d", step)
+		return
+	}
+	if step >= len(result.Steps) {
+		t.Errorf("expected at least %d steps but got %d", step+1, len(result.Steps))
+		return
+	}
+	if observation := result.Steps[step].Observation; !strings.Contains(observation, expected) {
+		t.Errorf("expected observation of step %d containing %q but got %q",
+			step, expected, observation)
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
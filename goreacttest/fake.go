@@ -0,0 +1,199 @@
+// Package goreacttest provides a scripted LLM provider and assertion
+// helpers for testing commands and prompts with goreact without
+// calling an LLM.
+package goreacttest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/dgruber/goreact"
+)
+
+// DefaultSummary is the response of the FakeProvider to summarization
+// requests unless configured otherwise with WithSummary.
+const DefaultSummary = "summary"
+
+// Call is a request received by the FakeProvider.
+type Call struct {
+	// Messages is the conversation sent by the ReAct loop. For
+	// summarization requests it contains the system and the user
+	// prompt.
+	Messages []goreact.Message
+	// Purpose tells whether the request was sent for reasoning, for
+	// compressing an observation or for verifying the answer.
+	Purpose goreact.Purpose
+	// Summarization is true for requests compressing an observation.
+	Summarization bool
+	Response      string
+}
+
+type rule struct {
+	match    string
+	response string
+	err      error
+}
+
+// FakeProvider is an LLM provider which returns scripted responses.
+// Responses registered with OnPrompt are returned when the last
+// message of the conversation contains the given text, all other
+// requests of the ReAct loop get the responses passed to
+// NewFakeProvider in sequence. When the script is exhausted an error
+// is returned.
+//
+// Summarization requests (see goreact.PromptSummarize) are answered
+// with the summary set by WithSummary and verification requests (see
+// goreact.PromptVerify) with the response set by WithVerification or
+// the Valid marker of the protocol, which accepts every answer.
+// The purpose is taken from the context of the request; requests
+// without a purpose are told apart by their system prompt.
+type FakeProvider struct {
+	mu           sync.Mutex
+	script       []string
+	rules        []rule
+	summary      string
+	verification string
+	calls        []Call
+	position     int
+}
+
+// NewFakeProvider returns a provider which responds with the given
+// responses in sequence, like "THOUGHT: ...\nACTION: calculate 1+1"
+// or "ANSWER: 2".
+func NewFakeProvider(responses ...string) *FakeProvider {
+	return &FakeProvider{
+		script:  responses,
+		summary: DefaultSummary,
+	}
+}
+
+// OnPrompt returns response whenever the last message contains match.
+// Rules are checked in the order they were added and take precedence
+// over the sequential script.
+func (f *FakeProvider) OnPrompt(match, response string) *FakeProvider {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = append(f.rules, rule{match: match, response: response})
+	return f
+}
+
+// FailOnPrompt returns err whenever the last message contains match.
+func (f *FakeProvider) FailOnPrompt(match string, err error) *FakeProvider {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = append(f.rules, rule{match: match, err: err})
+	return f
+}
+
+// WithSummary sets the response to summarization requests.
+func (f *FakeProvider) WithSummary(summary string) *FakeProvider {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.summary = summary
+	return f
+}
+
+// WithVerification sets the response to verification requests, like
+// "INVALID: the observations do not mention a coin".
+func (f *FakeProvider) WithVerification(response string) *FakeProvider {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.verification = response
+	return f
+}
+
+// Request answers summarization and verification requests.
+func (f *FakeProvider) Request(ctx context.Context, system, prompt string) (string, error) {
+	purpose := goreact.PurposeFromContext(ctx)
+	if purpose == "" {
+		purpose = goreact.PurposeSummarization
+		if isVerification(system) {
+			purpose = goreact.PurposeVerification
+		}
+	}
+	return f.respond(ctx, purpose, []goreact.Message{
+		{Role: goreact.RoleSystem, Content: system},
+		{Role: goreact.RoleUser, Content: prompt},
+	})
+}
+
+// Chat answers the requests of the ReAct loop.
+func (f *FakeProvider) Chat(ctx context.Context, messages []goreact.Message) (string, error) {
+	purpose := goreact.PurposeFromContext(ctx)
+	if purpose == "" {
+		purpose = goreact.PurposeReasoning
+	}
+	return f.respond(ctx, purpose, append([]goreact.Message(nil), messages...))
+}
+
+func (f *FakeProvider) respond(ctx context.Context, purpose goreact.Purpose, messages []goreact.Message) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var response string
+	var err error
+	switch purpose {
+	case goreact.PurposeSummarization:
+		response = f.summary
+	case goreact.PurposeVerification:
+		response = f.verification
+		if response == "" {
+			response = goreact.ProtocolFromContext(ctx).Valid
+		}
+	default:
+		last := ""
+		if len(messages) > 0 {
+			last = messages[len(messages)-1].Content
+		}
+		response, err = f.next(last)
+	}
+	f.calls = append(f.calls, Call{
+		Messages:      messages,
+		Purpose:       purpose,
+		Summarization: purpose == goreact.PurposeSummarization,
+		Response:      response,
+	})
+	return response, err
+}
+
+// isVerification recognizes the system prompt of verification
+// requests by the text of goreact.PromptVerify before its first
+// template action.
+func isVerification(system string) bool {
+	static, _, _ := strings.Cut(goreact.PromptVerify, "{{")
+	return static != "" && strings.HasPrefix(system, static)
+}
+
+func (f *FakeProvider) next(last string) (string, error) {
+	for _, rule := range f.rules {
+		if strings.Contains(last, rule.match) {
+			return rule.response, rule.err
+		}
+	}
+	if f.position >= len(f.script) {
+		return "", fmt.Errorf("no scripted response left after %d responses for: %s",
+			len(f.script), last)
+	}
+	response := f.script[f.position]
+	f.position++
+	return response, nil
+}
+
+// Calls returns all requests received so far.
+func (f *FakeProvider) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// Remaining returns the amount of sequential responses which were not
+// used yet.
+func (f *FakeProvider) Remaining() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.script) - f.position
+}
@@ -0,0 +1,142 @@
+package goreacttest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dgruber/goreact"
+)
+
+func look(argument string) (string, error) {
+	return "You see a coin.", nil
+}
+
+func purposes(calls []Call) []goreact.Purpose {
+	var purposes []goreact.Purpose
+	for _, call := range calls {
+		purposes = append(purposes, call.Purpose)
+	}
+	return purposes
+}
+
+func TestFakeProviderPurposes(t *testing.T) {
+	llm := NewFakeProvider(
+		"THOUGHT: I need to look.\nACTION: look north",
+		"ANSWER: There is 1 coin.",
+	).WithSummary("a coin")
+	reactor, err := goreact.NewReact(llm, map[string]goreact.Command{
+		"look": {Argument: "direction", Description: "look around", Func: look},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a summary size of 1 token forces the summarization of the
+	// observation, the small chunks split it into several requests
+	reactor.WithVerificationProvider(llm).WithContextLimits(goreact.ContextLimits{
+		ContextWindow: 14000, ChunkSize: 2, ChunkOverlap: goreact.NoOverlap, SummarySize: 1,
+	})
+
+	result, err := reactor.QuestionResult(context.Background(), "How many coins are there?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertAnswer(t, result, "1 coin")
+	if result.SummarizationCalls == 0 {
+		t.Error("expected the observation to be summarized")
+	}
+	if result.VerificationCalls != 1 {
+		t.Errorf("expected 1 verification call, got %d", result.VerificationCalls)
+	}
+	for _, call := range llm.Calls() {
+		want := map[goreact.Purpose]string{
+			goreact.PurposeSummarization: "a coin",
+			goreact.PurposeVerification:  goreact.DefaultProtocol.Valid,
+		}[call.Purpose]
+		if want != "" && call.Response != want {
+			t.Errorf("unexpected response %q to %s request", call.Response, call.Purpose)
+		}
+		if call.Summarization != (call.Purpose == goreact.PurposeSummarization) {
+			t.Errorf("unexpected summarization flag for %s request", call.Purpose)
+		}
+	}
+	if calls := purposes(llm.Calls()); calls[len(calls)-1] != goreact.PurposeVerification {
+		t.Errorf("expected the verification as last request, got %v", calls)
+	}
+	if llm.Remaining() != 0 {
+		t.Errorf("expected the script to be used up, %d responses left", llm.Remaining())
+	}
+}
+
+func TestFakeProviderRejectsAnswer(t *testing.T) {
+	llm := NewFakeProvider("ANSWER: There are 2 coins.").
+		WithVerification("INVALID: no coin was observed")
+	reactor, err := goreact.NewReact(llm, map[string]goreact.Command{
+		"look": {Argument: "direction", Description: "look around", Func: look},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reactor.WithVerificationProvider(llm)
+
+	// the script ends after the rejected answer
+	if _, err := reactor.QuestionResult(context.Background(), "How many coins are there?"); err == nil {
+		t.Fatal("expected an error after the script was exhausted")
+	}
+	calls := llm.Calls()
+	want := []goreact.Purpose{goreact.PurposeReasoning, goreact.PurposeVerification, goreact.PurposeReasoning}
+	if got := purposes(calls); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("expected purposes %v, got %v", want, got)
+	}
+	last := calls[2].Messages[len(calls[2].Messages)-1].Content
+	if !strings.Contains(last, "no coin was observed") {
+		t.Errorf("expected the rejection in the conversation, got %q", last)
+	}
+}
+
+func TestFakeProviderRequestWithoutPurpose(t *testing.T) {
+	llm := NewFakeProvider()
+	ctx := context.Background()
+	if response, _ := llm.Request(ctx, goreact.PromptVerify, "QUESTION: ?"); response != goreact.DefaultProtocol.Valid {
+		t.Errorf("expected a verification response, got %q", response)
+	}
+	if response, _ := llm.Request(ctx, "summarize", "text"); response != DefaultSummary {
+		t.Errorf("expected a summary, got %q", response)
+	}
+	want := []goreact.Purpose{goreact.PurposeVerification, goreact.PurposeSummarization}
+	if got := purposes(llm.Calls()); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected purposes %v, got %v", want, got)
+	}
+}
+
+// recorder records the failures of an assertion.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertObservationStep(t *testing.T) {
+	result := &goreact.Result{Steps: []goreact.Step{{Command: "look", Observation: "a coin"}}}
+	tests := []struct {
+		step   int
+		failed bool
+	}{
+		{step: 0, failed: false},
+		{step: 1, failed: true},
+		{step: -1, failed: true},
+	}
+	for _, test := range tests {
+		r := &recorder{TB: t}
+		AssertObservation(r, result, test.step, "coin")
+		if failed := len(r.errors) > 0; failed != test.failed {
+			t.Errorf("AssertObservation with step %d failed: %v %q", test.step, failed, r.errors)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package goreact
+
+import (
+	"context"
+	"time"
+)
+
+// EventType identifies what happened in the ReAct loop.
+type EventType int
+
+const (
+	// EventQuestion is emitted when answering a question starts.
+	EventQuestion EventType = iota
+	// EventLLMRequest is emitted before a request is sent to the LLM.
+	EventLLMRequest
+	// EventLLMResponse is emitted when the LLM responded or failed.
+	EventLLMResponse
+	// EventThought is emitted when a thought was parsed from the
+	// response of the LLM.
+	EventThought
+	// EventAction is emitted when an action was parsed from the
+	// response of the LLM, before the action hooks are called.
+	EventAction
+	// EventCommandStart is emitted before a command is executed.
+	EventCommandStart
+	// EventCommandFinish is emitted after a command was executed.
+	EventCommandFinish
+	// EventObservationCompressed is emitted when an observation was
+	// too long and got summarized.
+	EventObservationCompressed
+	// EventContextTruncated is emitted when the history got too large
+	// and the observations were removed from it.
+	EventContextTruncated
+	// EventAnswer is emitted when the final answer was found.
+	EventAnswer
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventQuestion:
+		return "question"
+	case EventLLMRequest:
+		return "llm request"
+	case EventLLMResponse:
+		return "llm response"
+	case EventThought:
+		return "thought"
+	case EventAction:
+		return "action"
+	case EventCommandStart:
+		return "command start"
+	case EventCommandFinish:
+		return "command finish"
+	case EventObservationCompressed:
+		return "observation compressed"
+	case EventContextTruncated:
+		return "context truncated"
+	case EventAnswer:
+		return "answer"
+	}
+	return "unknown"
+}
+
+// Event describes a step in the ReAct loop. Only the fields which
+// are relevant for the Type are set.
+type Event struct {
+	Type     EventType
+	Step     int
+	Question string
+	// System, Prompt, and Response are set for LLM requests and
+	// responses. Purpose tells for which role the request was sent.
+	System   string
+	Prompt   string
+	Response string
+	Purpose  Purpose
+	Thought  string
+	Command  string
+	Argument string
+	// Observation is the raw output of a command or the compressed
+	// observation for EventObservationCompressed.
+	Observation string
+	Answer      string
+	// Tokens is the estimated size of the prompt or the context.
+	Tokens int
+	// Usage contains the tokens and the cost of the request for
+	// EventLLMResponse.
+	Usage    Usage
+	Duration time.Duration
+	Err      error
+}
+
+// Observer is called synchronously for each event of the ReAct loop.
+type Observer func(ctx context.Context, event Event)
+
+// Action is a command with its argument which the LLM wants to
+// execute.
+type Action struct {
+	Command  string
+	Argument string
+}
+
+// ActionHook is called before an action is executed. It can rewrite
+// the action by modifying it. When it returns an error the action is
+// not executed and the error is sent back to the LLM as observation.
+type ActionHook func(ctx context.Context, step int, action *Action) error
+
+// WithObserver adds an observer which is called for every event of
+// the ReAct loop. Multiple observers are called in the order they
+// were added.
+func (r *React) WithObserver(observer Observer) *React {
+	r.observers = append(r.observers, observer)
+	return r
+}
+
+// WithActionHook adds a hook which can veto or rewrite actions before
+// they are executed. Multiple hooks are called in the order they
+// were added.
+func (r *React) WithActionHook(hook ActionHook) *React {
+	r.actionHooks = append(r.actionHooks, hook)
+	return r
+}
+
+func (r *React) emit(qr *run, event Event) {
+	if len(r.observers) == 0 {
+		return
+	}
+	event.Step = qr.steps
+	event.Question = qr.result.Question
+	for _, observer := range r.observers {
+		observer(qr.ctx, event)
+	}
+}
+
+// applyActionHooks calls all action hooks and stops at the first
+// hook which vetoes the action.
+func (r *React) applyActionHooks(qr *run, action *Action) error {
+	for _, hook := range r.actionHooks {
+		if err := hook(qr.ctx, qr.steps, action); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package goreact
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func lookCommands(arguments *[]string) map[string]Command {
+	return map[string]Command{
+		"look": {Argument: "direction", Description: "look around",
+			Func: func(argument string) (string, error) {
+				*arguments = append(*arguments, argument)
+				return "a coin in the " + argument, nil
+			}},
+	}
+}
+
+func TestObserverEventOrder(t *testing.T) {
+	llm := &scriptedProvider{responses: []string{
+		"THOUGHT: I look.\nACTION: look north",
+		"THOUGHT: I saw a coin.\nANSWER: 1 coin",
+	}}
+	var arguments []string
+	r, err := NewReact(llm, lookCommands(&arguments))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []Event
+	var second []EventType
+	r.WithObserver(func(ctx context.Context, event Event) {
+		events = append(events, event)
+	}).WithObserver(func(ctx context.Context, event Event) {
+		second = append(second, event.Type)
+	})
+	if _, err := r.Question("How many coins?"); err != nil {
+		t.Fatal(err)
+	}
+	var types []EventType
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	want := []EventType{
+		EventQuestion,
+		EventLLMRequest, EventLLMResponse, EventThought, EventAction, EventCommandStart, EventCommandFinish,
+		EventLLMRequest, EventLLMResponse, EventThought, EventAnswer,
+	}
+	if !slices.Equal(types, want) {
+		t.Fatalf("expected events %v, got %v", want, types)
+	}
+	if !slices.Equal(second, types) {
+		t.Errorf("the second observer got %v", second)
+	}
+	for _, event := range events {
+		if event.Question != "How many coins?" {
+			t.Errorf("%v has question %q", event.Type, event.Question)
+		}
+	}
+	finish := events[6]
+	if finish.Command != "look" || finish.Argument != "north" || finish.Observation != "a coin in the north" {
+		t.Errorf("unexpected command finish event %+v", finish)
+	}
+	if events[2].Purpose != PurposeReasoning || events[2].Response == "" {
+		t.Errorf("unexpected llm response event %+v", events[2])
+	}
+	if answer := events[len(events)-1]; answer.Answer != "1 coin" {
+		t.Errorf("unexpected answer event %+v", answer)
+	}
+}
+
+func TestActionHooks(t *testing.T) {
+	tests := []struct {
+		name        string
+		hooks       []ActionHook
+		arguments   []string
+		observation string
+	}{
+		{
+			name: "rewrite argument",
+			hooks: []ActionHook{func(ctx context.Context, step int, action *Action) error {
+				action.Argument = strings.ToUpper(action.Argument)
+				return nil
+			}},
+			arguments:   []string{"NORTH"},
+			observation: "a coin in the NORTH",
+		},
+		{
+			name: "reject action",
+			hooks: []ActionHook{func(ctx context.Context, step int, action *Action) error {
+				return errors.New("looking north is not allowed")
+			}},
+			observation: "The action was rejected: looking north is not allowed",
+		},
+		{
+			name: "first rejection stops the hooks",
+			hooks: []ActionHook{
+				func(ctx context.Context, step int, action *Action) error {
+					return errors.New("no")
+				},
+				func(ctx context.Context, step int, action *Action) error {
+					t.Error("the second hook was called")
+					return nil
+				},
+			},
+			observation: "The action was rejected: no",
+		},
+		{
+			name: "hooks in order",
+			hooks: []ActionHook{
+				func(ctx context.Context, step int, action *Action) error {
+					action.Argument += " east"
+					return nil
+				},
+				func(ctx context.Context, step int, action *Action) error {
+					if step != 1 {
+						t.Errorf("unexpected step %d", step)
+					}
+					action.Argument += " south"
+					return nil
+				},
+			},
+			arguments:   []string{"north east south"},
+			observation: "a coin in the north east south",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			llm := &scriptedProvider{responses: []string{
+				"THOUGHT: I look.\nACTION: look north",
+				"ANSWER: done",
+			}}
+			var arguments []string
+			r, err := NewReact(llm, lookCommands(&arguments))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, hook := range test.hooks {
+				r.WithActionHook(hook)
+			}
+			result, err := r.QuestionResult(context.Background(), "How many coins?")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(arguments, test.arguments) {
+				t.Errorf("expected the command to be called with %q, got %q", test.arguments, arguments)
+			}
+			step := result.Steps[0]
+			if step.Observation != test.observation {
+				t.Errorf("expected observation %q, got %q", test.observation, step.Observation)
+			}
+			if (test.arguments == nil) != (step.Err != nil) {
+				t.Errorf("unexpected error %v", step.Err)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package goreact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrStepLimit is returned when the ReAct loop needs more
+	// iterations than configured with WithMaxSteps.
+	ErrStepLimit = errors.New("step limit exceeded")
+	// ErrTimeLimit is returned when answering the question takes
+	// longer than configured with WithMaxDuration.
+	ErrTimeLimit = errors.New("time limit exceeded")
+	// ErrTokenLimit is returned when the amount of tokens sent to and
+	// received from the LLM exceeds WithMaxTokens.
+	ErrTokenLimit = errors.New("token limit exceeded")
+)
+
+// DefaultMaxSteps is the amount of loop iterations a question may
+// take unless configured otherwise with WithMaxSteps.
+const DefaultMaxSteps = 20
+
+// LimitError is returned when one of the configured limits stops the
+// ReAct loop. Limit is one of ErrStepLimit, ErrTimeLimit, or
+// ErrTokenLimit so that errors.Is can be used to check which limit
+// was hit. When WithFinalAnswerOnLimit is enabled Answer contains the
+// best-effort answer of the LLM based on the observations so far.
+type LimitError struct {
+	Limit   error
+	Steps   int
+	Tokens  int
+	Elapsed time.Duration
+	Answer  string
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v after %d steps, around %d tokens, and %s",
+		e.Limit, e.Steps, e.Tokens, e.Elapsed.Round(time.Millisecond))
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Limit
+}
+
+// WithMaxSteps limits the amount of iterations of the ReAct loop
+// including the retries when the LLM does not return an ACTION.
+// With tool calling each executed tool call is a step. 0 means no
+// limit.
+func (r *React) WithMaxSteps(steps int) *React {
+	r.maxSteps = steps
+	return r
+}
+
+// WithMaxDuration limits the wall-clock time for answering a question.
+// 0 means no limit.
+func (r *React) WithMaxDuration(d time.Duration) *React {
+	r.maxDuration = d
+	return r
+}
+
+// WithMaxTokens limits the amount of tokens (prompt and response of
+// all LLM requests) for answering a question. The tokens reported by
+// the providers are used, or estimates when a provider does not
+// report them. 0 means no limit.
+func (r *React) WithMaxTokens(tokens int) *React {
+	r.maxTokens = tokens
+	return r
+}
+
+// WithFinalAnswerOnLimit lets the LLM write a best-effort answer
+// based on the observations gathered so far when a limit is hit.
+// The answer is returned together with the LimitError. It stays empty
+// when the response of the LLM contains no answer.
+func (r *React) WithFinalAnswerOnLimit(enabled bool) *React {
+	r.finalAnswerOnLimit = enabled
+	return r
+}
+
+// run keeps track of the state of answering a single question.
+type run struct {
+	ctx    context.Context
+	parent context.Context
+	cancel context.CancelFunc
+	start  time.Time
+	steps  int
+	result *Result
+}
+
+func (r *React) newRun(ctx context.Context, question string) *run {
+	qr := &run{
+		parent: ctx,
+		start:  time.Now(),
+		result: &Result{Question: question},
+	}
+	if r.maxDuration > 0 {
+		qr.ctx, qr.cancel = context.WithTimeout(ctx, r.maxDuration)
+	} else {
+		qr.ctx, qr.cancel = context.WithCancel(ctx)
+	}
+	return qr
+}
+
+// newStep appends a new step to the result and returns it.
+func (qr *run) newStep() *Step {
+	qr.result.Steps = append(qr.result.Steps, Step{})
+	return &qr.result.Steps[len(qr.result.Steps)-1]
+}
+
+// step counts one iteration of the loop and checks all limits.
+func (r *React) step(qr *run) error {
+	if err := r.checkSteps(qr); err != nil {
+		return err
+	}
+	qr.steps++
+	return r.checkLimits(qr)
+}
+
+// checkSteps returns the step limit error when no step is left.
+func (r *React) checkSteps(qr *run) error {
+	if r.maxSteps > 0 && qr.steps >= r.maxSteps {
+		return r.limitError(qr, ErrStepLimit)
+	}
+	return nil
+}
+
+func (r *React) checkLimits(qr *run) error {
+	if r.maxTokens > 0 && qr.result.TotalTokens() > r.maxTokens {
+		return r.limitError(qr, ErrTokenLimit)
+	}
+	if r.maxDuration > 0 && time.Since(qr.start) > r.maxDuration {
+		return r.limitError(qr, ErrTimeLimit)
+	}
+	return qr.parent.Err()
+}
+
+// checkError turns a cancellation caused by the time limit into a
+// LimitError.
+func (r *React) checkError(qr *run, err error) error {
+	if err == nil {
+		return nil
+	}
+	var limitErr *LimitError
+	if errors.As(err, &limitErr) {
+		return err
+	}
+	if qr.ctx.Err() != nil && qr.parent.Err() == nil {
+		return r.limitError(qr, ErrTimeLimit)
+	}
+	return err
+}
+
+func (r *React) limitError(qr *run, limit error) *LimitError {
+	return &LimitError{
+		Limit:   limit,
+		Steps:   qr.steps,
+		Tokens:  qr.result.TotalTokens(),
+		Elapsed: time.Since(qr.start),
+	}
+}
+
+// llmRequest describes a request to the LLM for the events and the
+// accounting of its usage.
+type llmRequest struct {
+	purpose  Purpose
+	provider any
+	system   string
+	prompt   string
+	// tokens is the estimated size of the prompt.
+	tokens int
+}
+
+// request checks the limits and sends the request with send.
+func (r *React) request(qr *run, req llmRequest, send func(ctx context.Context) (string, error)) (string, error) {
+	if err := r.checkLimits(qr); err != nil {
+		return "", err
+	}
+	response, err := r.send(qr, qr.ctx, req, send)
+	if err != nil {
+		return "", r.checkError(qr, err)
+	}
+	return response, nil
+}
+
+// send sends the request with send and accounts the tokens reported
+// by the provider. The tokens are estimated when the provider does
+// not report them.
+func (r *React) send(qr *run, ctx context.Context, req llmRequest, send func(ctx context.Context) (string, error)) (string, error) {
+	qr.result.LLMCalls++
+	switch req.purpose {
+	case PurposeSummarization:
+		qr.result.SummarizationCalls++
+	case PurposeVerification:
+		qr.result.VerificationCalls++
+	}
+	r.emit(qr, Event{Type: EventLLMRequest, System: req.system, Prompt: req.prompt,
+		Purpose: req.purpose, Tokens: req.tokens})
+	ctx = ContextWithPurpose(ContextWithProtocol(ctx, r.protocol), req.purpose)
+	ctx, collector := withUsageCollector(ctx)
+	started := time.Now()
+	response, err := send(ctx)
+	duration := time.Since(started)
+
+	usage, reported := collector.collected()
+	if !reported && err == nil {
+		usage = Usage{
+			Model:            modelOf(req.provider),
+			PromptTokens:     req.tokens,
+			CompletionTokens: r.countTokens(response),
+			Estimated:        true,
+		}
+	}
+	usage.Cost = r.pricing.Cost(usage)
+	qr.result.addUsage(req.purpose, usage)
+	r.emit(qr, Event{Type: EventLLMResponse, Response: response, Purpose: req.purpose,
+		Usage: usage, Duration: duration, Err: err})
+	return response, err
+}
+
+// summarize sends a request for compressing an observation to the
+// summarization provider.
+func (r *React) summarize(qr *run, system, prompt string) (string, error) {
+	provider := r.summarizationProvider()
+	return r.request(qr, llmRequest{
+		purpose:  PurposeSummarization,
+		provider: provider,
+		system:   system,
+		prompt:   prompt,
+		tokens:   r.countTokens(system) + r.countTokens(prompt),
+	}, func(ctx context.Context) (string, error) {
+		return provider.Request(ctx, system, prompt)
+	})
+}
+
+// chat sends the conversation to the LLM.
+func (r *React) chat(qr *run, chat ChatProvider, messages []Message) (string, error) {
+	return r.request(qr, r.reasoningRequest(messages), func(ctx context.Context) (string, error) {
+		return chat.Chat(ctx, messages)
+	})
+}
+
+func (r *React) reasoningRequest(messages []Message) llmRequest {
+	return llmRequest{
+		purpose:  PurposeReasoning,
+		provider: r.llm,
+		system:   messages[0].Content,
+		prompt:   messages[len(messages)-1].Content,
+		tokens:   r.countMessageTokens(messages),
+	}
+}
+
+// finalAnswer asks the LLM for a best-effort answer based on the
+// history when a limit was hit. It uses the parent context as the
+// context of the run might already be expired.
+func (r *React) finalAnswer(qr *run, limitErr *LimitError, history []Message) (string, error) {
+	// the history needs more than the system prompt and the question
+	if !r.finalAnswerOnLimit || len(history) <= 2 {
+		return "", limitErr
+	}
+	messages := append(history[:len(history):len(history)], Message{
+		Role: RoleUser,
+		Content: fmt.Sprintf("The %v. No more actions can be executed. "+
+			"Answer the question as good as possible based on the observations so far "+
+			"and start your response with %s ", limitErr.Limit, r.protocol.Answer),
+	})
+	chat := r.chatProvider()
+	answer, err := r.send(qr, qr.parent, r.reasoningRequest(messages), func(ctx context.Context) (string, error) {
+		return chat.Chat(ctx, messages)
+	})
+	if err != nil {
+		return "", limitErr
+	}
+	// a response without answer, e.g. another action, is no answer
+	if r.protocol.isAnswer(answer) {
+		limitErr.Answer = r.protocol.extractAnswer(answer)
+	}
+	return limitErr.Answer, limitErr
+}
@@ -0,0 +1,58 @@
+package goreact
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFinalAnswerOnLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		answer   string
+	}{
+		{"answer", "THOUGHT: I saw one coin.\nANSWER: 1 coin", "1 coin"},
+		{"action instead of answer", "THOUGHT: again\nACTION: look north", ""},
+		{"plain text", "There is one coin.", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			llm := &scriptedProvider{responses: []string{
+				"THOUGHT: I look.\nACTION: look north",
+				test.response,
+			}}
+			r, err := NewReact(llm, map[string]Command{
+				"look": {Argument: "direction", Description: "look around",
+					Func: func(string) (string, error) { return "a coin", nil }},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.WithMaxSteps(1).WithFinalAnswerOnLimit(true)
+			answer, err := r.Question("How many coins?")
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) || !errors.Is(err, ErrStepLimit) {
+				t.Fatalf("expected a step limit error, got %v", err)
+			}
+			if answer != test.answer || limitErr.Answer != test.answer {
+				t.Errorf("expected answer %q, got %q and %q", test.answer, answer, limitErr.Answer)
+			}
+		})
+	}
+}
+
+func TestFinalAnswerOnLimitDisabled(t *testing.T) {
+	llm := &scriptedProvider{responses: []string{"THOUGHT: I look.\nACTION: look north"}}
+	r, err := NewReact(llm, map[string]Command{
+		"look": {Func: func(string) (string, error) { return "a coin", nil }},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.WithMaxSteps(1).QuestionContext(context.Background(), "How many coins?")
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || limitErr.Answer != "" || len(llm.responses) != 0 {
+		t.Errorf("expected a limit error without answer, got %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package goreact
+
+import (
+	"context"
+	"log/slog"
+)
+
+// WithLogger sets the logger which receives the progress of the
+// ReAct loop (questions, thoughts, actions, observations, and
+// answers). By default nothing is logged.
+func (r *React) WithLogger(logger *slog.Logger) *React {
+	if logger == nil {
+		logger = newDiscardLogger()
+	}
+	r.logger = logger
+	return r
+}
+
+func newDiscardLogger() *slog.Logger {
+	return slog.New(discardHandler{})
+}
+
+// discardHandler is a slog.Handler which drops all records.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (d discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return d }
+func (d discardHandler) WithGroup(string) slog.Handler           { return d }
@@ -0,0 +1,173 @@
+package goreact
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultOllamaURL is the address of a local Ollama server.
+const DefaultOllamaURL = "http://localhost:11434"
+
+// OllamaProvider sends requests to the /api/chat endpoint of an
+// Ollama server so that no data leaves the machine.
+type OllamaProvider struct {
+	client      *http.Client
+	baseURL     string
+	model       string
+	temperature float64
+	stop        []string
+}
+
+func NewOllamaProvider(model string) (*OllamaProvider, error) {
+	if model == "" {
+		return nil, fmt.Errorf("model cannot be empty")
+	}
+	return &OllamaProvider{
+		client:      http.DefaultClient,
+		baseURL:     DefaultOllamaURL,
+		model:       model,
+		temperature: 0.1,
+	}, nil
+}
+
+func (o *OllamaProvider) WithModel(model string) *OllamaProvider {
+	o.model = model
+	return o
+}
+
+func (o *OllamaProvider) Model() string {
+	return o.model
+}
+
+// WithBaseURL sets the address of the Ollama server.
+func (o *OllamaProvider) WithBaseURL(baseURL string) *OllamaProvider {
+	o.baseURL = strings.TrimSuffix(baseURL, "/")
+	return o
+}
+
+func (o *OllamaProvider) WithTemperature(temperature float64) *OllamaProvider {
+	o.temperature = temperature
+	return o
+}
+
+// WithStop replaces the stop sequences. By default the generation
+// stops at the stop sequences of the protocol of the request, see
+// ProtocolFromContext.
+func (o *OllamaProvider) WithStop(stop ...string) *OllamaProvider {
+	o.stop = append([]string{}, stop...)
+	return o
+}
+
+func (o *OllamaProvider) WithHTTPClient(client *http.Client) *OllamaProvider {
+	o.client = client
+	return o
+}
+
+type ollamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ollamaOptions struct {
+	Temperature float64  `json:"temperature"`
+	Stop        []string `json:"stop,omitempty"`
+}
+
+type ollamaChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Options  ollamaOptions   `json:"options"`
+}
+
+type ollamaChatResponse struct {
+	Model           string        `json:"model"`
+	Message         ollamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+	Error           string        `json:"error"`
+}
+
+func (o *OllamaProvider) Request(ctx context.Context, system, prompt string) (string, error) {
+	return o.Chat(ctx, []Message{
+		{Role: RoleSystem, Content: system},
+		{Role: RoleUser, Content: prompt},
+	})
+}
+
+// Chat sends the whole conversation to Ollama.
+func (o *OllamaProvider) Chat(ctx context.Context, messages []Message) (string, error) {
+	req := ollamaChatRequest{
+		Model:  o.model,
+		Stream: false,
+		Options: ollamaOptions{
+			Temperature: o.temperature,
+			Stop:        stopSequences(ctx, o.stop),
+		},
+	}
+	for _, message := range messages {
+		role := message.Role
+		if role == RoleTool {
+			role = RoleUser
+		}
+		req.Messages = append(req.Messages, ollamaMessage{
+			Role:    role,
+			Content: message.Content,
+		})
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		o.baseURL+"/api/chat", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := o.client.Do(httpReq)
+	if err != nil {
+		return "", err
+	}
+	defer httpResp.Body.Close()
+
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	var resp ollamaChatResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		if httpResp.StatusCode != http.StatusOK {
+			return "", &ProviderError{
+				Provider:   "ollama",
+				StatusCode: httpResp.StatusCode,
+				Message:    strings.TrimSpace(string(data)),
+				RetryAfter: parseRetryAfter(httpResp.Header.Get("Retry-After")),
+			}
+		}
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if httpResp.StatusCode != http.StatusOK || resp.Error != "" {
+		return "", &ProviderError{
+			Provider:   "ollama",
+			StatusCode: httpResp.StatusCode,
+			Message:    resp.Error,
+			RetryAfter: parseRetryAfter(httpResp.Header.Get("Retry-After")),
+		}
+	}
+	if resp.PromptEvalCount > 0 || resp.EvalCount > 0 {
+		ReportUsage(ctx, Usage{
+			Model:            resp.Model,
+			PromptTokens:     resp.PromptEvalCount,
+			CompletionTokens: resp.EvalCount,
+		})
+	}
+	return resp.Message.Content, nil
+}
@@ -0,0 +1,164 @@
+package goreact
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+func newOllamaServer(t *testing.T, handler http.HandlerFunc) *OllamaProvider {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	provider, err := NewOllamaProvider("llama3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider.WithBaseURL(server.URL + "/")
+}
+
+func TestOllamaProviderChatRequest(t *testing.T) {
+	var got ollamaChatRequest
+	provider := newOllamaServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/chat" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		w.Write([]byte(`{"model":"llama3","message":{"role":"assistant","content":"ANSWER: 42"},"done":true}`))
+	})
+
+	protocol := DefaultProtocol
+	protocol.Stop = []string{"BEOBACHTUNG:"}
+	ctx := ContextWithProtocol(context.Background(), protocol)
+	response, err := provider.Chat(ctx, []Message{
+		{Role: RoleSystem, Content: "system"},
+		{Role: RoleUser, Content: "question"},
+		{Role: RoleTool, Content: "tool output"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response != "ANSWER: 42" {
+		t.Errorf("unexpected response %q", response)
+	}
+	if got.Model != "llama3" || got.Stream {
+		t.Errorf("unexpected model %q or stream %v", got.Model, got.Stream)
+	}
+	if !slices.Equal(got.Options.Stop, []string{"BEOBACHTUNG:"}) {
+		t.Errorf("unexpected stop sequences %v", got.Options.Stop)
+	}
+	roles := []string{}
+	for _, message := range got.Messages {
+		roles = append(roles, message.Role)
+	}
+	if !slices.Equal(roles, []string{RoleSystem, RoleUser, RoleUser}) {
+		t.Errorf("unexpected roles %v", roles)
+	}
+}
+
+func TestOllamaProviderStop(t *testing.T) {
+	var got ollamaChatRequest
+	provider := newOllamaServer(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"message":{"role":"assistant","content":"ok"},"done":true}`))
+	})
+
+	if _, err := provider.Request(context.Background(), "system", "prompt"); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got.Options.Stop, DefaultProtocol.StopSequences()) {
+		t.Errorf("expected the stop sequences of the default protocol, got %v", got.Options.Stop)
+	}
+
+	provider.WithStop("END")
+	if _, err := provider.Request(context.Background(), "system", "prompt"); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got.Options.Stop, []string{"END"}) {
+		t.Errorf("expected the configured stop sequences, got %v", got.Options.Stop)
+	}
+}
+
+func TestOllamaProviderErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		retryAfter string
+		message    string
+		overloaded bool
+	}{
+		{
+			name:    "json error",
+			status:  http.StatusNotFound,
+			body:    `{"error":"model \"llama3\" not found"}`,
+			message: `model "llama3" not found`,
+		},
+		{
+			name:       "plain text error",
+			status:     http.StatusServiceUnavailable,
+			body:       "server busy\n",
+			retryAfter: "2",
+			message:    "server busy",
+			overloaded: true,
+		},
+		{
+			name:    "error with status 200",
+			status:  http.StatusOK,
+			body:    `{"error":"out of memory"}`,
+			message: "out of memory",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider := newOllamaServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if test.retryAfter != "" {
+					w.Header().Set("Retry-After", test.retryAfter)
+				}
+				w.WriteHeader(test.status)
+				w.Write([]byte(test.body))
+			})
+			_, err := provider.Request(context.Background(), "system", "prompt")
+			var providerErr *ProviderError
+			if !errors.As(err, &providerErr) {
+				t.Fatalf("expected a *ProviderError, got %v", err)
+			}
+			if providerErr.Provider != "ollama" || providerErr.StatusCode != test.status ||
+				providerErr.Message != test.message {
+				t.Errorf("unexpected error %+v", providerErr)
+			}
+			if errors.Is(err, ErrOverloaded) != test.overloaded {
+				t.Errorf("errors.Is(err, ErrOverloaded) = %v", !test.overloaded)
+			}
+			if test.retryAfter != "" && providerErr.RetryAfter != 2*time.Second {
+				t.Errorf("unexpected retry after %v", providerErr.RetryAfter)
+			}
+		})
+	}
+}
+
+func TestOllamaProviderUsage(t *testing.T) {
+	provider := newOllamaServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"model":"llama3:8b","message":{"role":"assistant","content":"ok"},"done":true,"prompt_eval_count":26,"eval_count":3}`))
+	})
+
+	ctx, collector := withUsageCollector(context.Background())
+	if _, err := provider.Request(ctx, "system", "prompt"); err != nil {
+		t.Fatal(err)
+	}
+	usage, reported := collector.collected()
+	if !reported {
+		t.Fatal("usage was not reported")
+	}
+	want := Usage{Model: "llama3:8b", PromptTokens: 26, CompletionTokens: 3}
+	if usage != want {
+		t.Errorf("expected usage %+v, got %+v", want, usage)
+	}
+}
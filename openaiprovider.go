@@ -2,25 +2,48 @@ package goreact
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
 
 	openai "github.com/sashabaranov/go-openai"
 )
 
+// LLMProvider sends a system and a user prompt to a language model
+// and returns its response. Implementations must honour the
+// cancellation and deadline of ctx.
 type LLMProvider interface {
-	Request(system, prompt string) (string, error)
+	Request(ctx context.Context, system, prompt string) (string, error)
 }
 
+// OpenAIProvider sends requests to the OpenAI chat completion API or
+// any OpenAI compatible server like vLLM, the llama.cpp server, LM
+// Studio, or Azure OpenAI.
 type OpenAIProvider struct {
-	client *openai.Client
-	model  string
+	apiKey      string
+	config      openai.ClientConfig
+	client      *openai.Client
+	model       string
+	temperature float32
+	topP        float32
+	maxTokens   int
+	seed        *int
+	stop        []string
+	user        string
 }
 
 func NewOpenAIProvider(openaikey string) (*OpenAIProvider, error) {
-	client := openai.NewClient(openaikey)
-	return &OpenAIProvider{
-		client: client,
-		model:  openai.GPT4o,
-	}, nil
+	provider := &OpenAIProvider{
+		apiKey:      openaikey,
+		config:      openai.DefaultConfig(openaikey),
+		model:       openai.GPT4o,
+		temperature: 0.1,
+		user:        "goreact",
+	}
+	return provider.reconfigure(), nil
 }
 
 func (o *OpenAIProvider) WithModel(model string) *OpenAIProvider {
@@ -28,27 +51,295 @@ func (o *OpenAIProvider) WithModel(model string) *OpenAIProvider {
 	return o
 }
 
-func (o *OpenAIProvider) Request(system, prompt string) (string, error) {
-	req := openai.ChatCompletionRequest{
+func (o *OpenAIProvider) Model() string {
+	return o.model
+}
+
+// WithBaseURL sets the address of an OpenAI compatible API, like
+// http://localhost:8000/v1 for vLLM.
+func (o *OpenAIProvider) WithBaseURL(baseURL string) *OpenAIProvider {
+	o.config.BaseURL = baseURL
+	return o.reconfigure()
+}
+
+func (o *OpenAIProvider) WithOrganization(organization string) *OpenAIProvider {
+	o.config.OrgID = organization
+	return o.reconfigure()
+}
+
+// WithAzure configures the provider for an Azure OpenAI endpoint. The
+// model is mapped to the deployment name. An empty apiVersion keeps
+// the default API version of the client library.
+func (o *OpenAIProvider) WithAzure(endpoint, apiVersion string) *OpenAIProvider {
+	config := openai.DefaultAzureConfig(o.apiKey, endpoint)
+	if apiVersion != "" {
+		config.APIVersion = apiVersion
+	}
+	config.OrgID = o.config.OrgID
+	config.HTTPClient = o.config.HTTPClient
+	o.config = config
+	return o.reconfigure()
+}
+
+// WithHTTPClient sets the HTTP client which is used for all requests.
+func (o *OpenAIProvider) WithHTTPClient(client *http.Client) *OpenAIProvider {
+	o.config.HTTPClient = client
+	return o.reconfigure()
+}
+
+// WithTimeout sets the timeout of each request by using an HTTP
+// client with that timeout.
+func (o *OpenAIProvider) WithTimeout(timeout time.Duration) *OpenAIProvider {
+	return o.WithHTTPClient(&http.Client{Timeout: timeout})
+}
+
+// WithTemperature sets the sampling temperature (default 0.1). As the
+// client omits a temperature of 0, it is sent as the smallest positive
+// float32 which the API treats as greedy sampling.
+func (o *OpenAIProvider) WithTemperature(temperature float32) *OpenAIProvider {
+	if temperature == 0 {
+		temperature = math.SmallestNonzeroFloat32
+	}
+	o.temperature = temperature
+	return o
+}
+
+func (o *OpenAIProvider) WithTopP(topP float32) *OpenAIProvider {
+	o.topP = topP
+	return o
+}
+
+// WithMaxTokens limits the amount of tokens of each response.
+// 0 means no limit.
+func (o *OpenAIProvider) WithMaxTokens(maxTokens int) *OpenAIProvider {
+	o.maxTokens = maxTokens
+	return o
+}
+
+// WithSeed requests deterministic sampling from models supporting it.
+func (o *OpenAIProvider) WithSeed(seed int) *OpenAIProvider {
+	o.seed = &seed
+	return o
+}
+
+// WithStop replaces the stop sequences. By default the generation
+// stops at the stop sequences of the protocol of the request, see
+// ProtocolFromContext.
+func (o *OpenAIProvider) WithStop(stop ...string) *OpenAIProvider {
+	o.stop = append([]string{}, stop...)
+	return o
+}
+
+// WithUser sets the end-user identifier sent to OpenAI (default
+// goreact).
+func (o *OpenAIProvider) WithUser(user string) *OpenAIProvider {
+	o.user = user
+	return o
+}
+
+func (o *OpenAIProvider) reconfigure() *OpenAIProvider {
+	config := o.config
+	doer := config.HTTPClient
+	if doer == nil {
+		doer = http.DefaultClient
+	}
+	config.HTTPClient = retryAfterRecorder{doer: doer}
+	o.client = openai.NewClientWithConfig(config)
+	return o
+}
+
+// retryAfterKey carries a pointer to the Retry-After header of the
+// response in the context of the request, as the errors of the OpenAI
+// client do not contain the headers.
+type retryAfterKey struct{}
+
+// retryAfterRecorder records the Retry-After header of the responses.
+type retryAfterRecorder struct {
+	doer openai.HTTPDoer
+}
+
+func (r retryAfterRecorder) Do(req *http.Request) (*http.Response, error) {
+	resp, err := r.doer.Do(req)
+	if retryAfter, ok := req.Context().Value(retryAfterKey{}).(*string); ok && resp != nil {
+		*retryAfter = resp.Header.Get("Retry-After")
+	}
+	return resp, err
+}
+
+// createChatCompletion sends the request and turns the errors of the
+// API into a *ProviderError with the requested Retry-After delay.
+func (o *OpenAIProvider) createChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	var retryAfter string
+	resp, err := o.client.CreateChatCompletion(context.WithValue(ctx, retryAfterKey{}, &retryAfter), req)
+	if err == nil {
+		return resp, nil
+	}
+	providerErr := &ProviderError{Provider: "openai", Err: err, RetryAfter: parseRetryAfter(retryAfter)}
+	var apiErr *openai.APIError
+	var requestErr *openai.RequestError
+	switch {
+	case errors.As(err, &apiErr):
+		providerErr.StatusCode = apiErr.HTTPStatusCode
+		providerErr.Type = apiErr.Type
+		providerErr.Message = apiErr.Message
+	case errors.As(err, &requestErr):
+		providerErr.StatusCode = requestErr.HTTPStatusCode
+		providerErr.Message = err.Error()
+	}
+	if providerErr.StatusCode == 0 {
+		// e.g. network errors
+		return resp, err
+	}
+	return resp, providerErr
+}
+
+// newRequest returns a request with all configured sampling options.
+func (o *OpenAIProvider) newRequest(messages []Message) openai.ChatCompletionRequest {
+	return openai.ChatCompletionRequest{
 		Model:       o.model,
-		Temperature: 0.1,
-		Stop:        []string{"OBSERVATION:", "STOP_ACTION"},
-		User:        "goreact",
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleSystem,
-				Content: system,
-			},
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: prompt,
-			},
-		},
+		Temperature: o.temperature,
+		TopP:        o.topP,
+		MaxTokens:   o.maxTokens,
+		Seed:        o.seed,
+		User:        o.user,
+		Messages:    toOpenAIMessages(messages),
 	}
+}
+
+func (o *OpenAIProvider) Request(ctx context.Context, system, prompt string) (string, error) {
+	return o.Chat(ctx, []Message{
+		{Role: RoleSystem, Content: system},
+		{Role: RoleUser, Content: prompt},
+	})
+}
+
+// Chat sends the whole conversation to OpenAI.
+func (o *OpenAIProvider) Chat(ctx context.Context, messages []Message) (string, error) {
+	req := o.newRequest(messages)
+	req.Stop = stopSequences(ctx, o.stop)
 
-	resp, err := o.client.CreateChatCompletion(context.Background(), req)
+	resp, err := o.createChatCompletion(ctx, req)
 	if err != nil {
 		return "", err
 	}
+	reportOpenAIUsage(ctx, resp)
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no choices in response")
+	}
 	return resp.Choices[0].Message.Content, nil
 }
+
+// RequestTools sends the conversation together with the tool
+// definitions to OpenAI. ErrToolsNotSupported is returned when the
+// model rejects tools.
+func (o *OpenAIProvider) RequestTools(ctx context.Context, messages []Message, tools []Tool) (Message, error) {
+	req := o.newRequest(messages)
+	for _, tool := range tools {
+		req.Tools = append(req.Tools, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+
+	resp, err := o.createChatCompletion(ctx, req)
+	if err != nil {
+		if toolsRejected(err) {
+			return Message{}, fmt.Errorf("%w: %v", ErrToolsNotSupported, err)
+		}
+		return Message{}, err
+	}
+	reportOpenAIUsage(ctx, resp)
+	if len(resp.Choices) == 0 {
+		return Message{}, fmt.Errorf("no choices in response")
+	}
+	choice := resp.Choices[0].Message
+	response := Message{
+		Role:    RoleAssistant,
+		Content: choice.Content,
+	}
+	for _, call := range choice.ToolCalls {
+		response.ToolCalls = append(response.ToolCalls, ToolCall{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: call.Function.Arguments,
+		})
+	}
+	return response, nil
+}
+
+// reportOpenAIUsage reports the token usage of the response. Some
+// OpenAI compatible servers do not return the usage, then the tokens
+// are estimated.
+func reportOpenAIUsage(ctx context.Context, resp openai.ChatCompletionResponse) {
+	if resp.Usage.TotalTokens == 0 {
+		return
+	}
+	ReportUsage(ctx, Usage{
+		Model:            resp.Model,
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+	})
+}
+
+func toOpenAIMessages(messages []Message) []openai.ChatCompletionMessage {
+	var result []openai.ChatCompletionMessage
+	for _, message := range messages {
+		m := openai.ChatCompletionMessage{
+			Role:       message.Role,
+			Content:    message.Content,
+			ToolCallID: message.ToolCallID,
+		}
+		for _, call := range message.ToolCalls {
+			m.ToolCalls = append(m.ToolCalls, openai.ToolCall{
+				ID:   call.ID,
+				Type: openai.ToolTypeFunction,
+				Function: openai.FunctionCall{
+					Name:      call.Name,
+					Arguments: call.Arguments,
+				},
+			})
+		}
+		result = append(result, m)
+	}
+	return result
+}
+
+// toolsRejectedMessages are the error messages of OpenAI compatible
+// servers for models which cannot call tools, like "tools is not
+// supported in this model" of OpenAI or "llama2 does not support
+// tools" of Ollama.
+var toolsRejectedMessages = []string{
+	"does not support tools",
+	"tools is not supported",
+	"tools are not supported",
+	"tool use is not supported",
+	"tool calling is not supported",
+	"tool_choice is not supported",
+	"function calling is not supported",
+	"unrecognized request argument supplied: tools",
+}
+
+// toolsRejected reports whether err is the rejection of a request
+// because the model cannot call tools. An invalid tool definition is
+// no such rejection, it is reported as error.
+func toolsRejected(err error) bool {
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != http.StatusBadRequest {
+		return false
+	}
+	message := strings.ToLower(apiErr.Message)
+	if apiErr.Param != nil && (*apiErr.Param == "tools" || *apiErr.Param == "tool_choice") &&
+		strings.Contains(message, "support") {
+		return true
+	}
+	for _, rejected := range toolsRejectedMessages {
+		if strings.Contains(message, rejected) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package goreact
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestOpenAIProviderRetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", "4")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`))
+	}))
+	defer server.Close()
+	provider, err := NewOpenAIProvider("key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider.WithBaseURL(server.URL + "/v1")
+
+	_, err = provider.Request(context.Background(), "system", "prompt")
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) {
+		t.Fatalf("expected a *ProviderError, got %T: %v", err, err)
+	}
+	if providerErr.RetryAfter != 4*time.Second || providerErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("unexpected error %+v", providerErr)
+	}
+	if retryAfter(err) != 4*time.Second || ClassifyError(err) != ErrorRateLimit {
+		t.Errorf("unexpected retry after %v or class %v", retryAfter(err), ClassifyError(err))
+	}
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "Rate limit reached" {
+		t.Errorf("expected the *openai.APIError, got %v", apiErr)
+	}
+}
+
+func TestOpenAIProviderToolsNotSupported(t *testing.T) {
+	tests := []struct {
+		body        string
+		unsupported bool
+	}{
+		{`{"error":{"message":"tools is not supported in this model","type":"invalid_request_error","param":"tools"}}`, true},
+		{`{"error":{"message":"registry.ollama.ai/library/llama2:latest does not support tools","type":"api_error"}}`, true},
+		{`{"error":{"message":"Unrecognized request argument supplied: tools","type":"invalid_request_error"}}`, true},
+		{`{"error":{"message":"Invalid schema for function 'look': the tool definition is not supported","type":"invalid_request_error","param":"tools[0].function.parameters"}}`, false},
+		{`{"error":{"message":"This model's maximum context length is 4096 tokens","type":"invalid_request_error","param":"messages"}}`, false},
+	}
+	for _, test := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(test.body))
+		}))
+		provider, err := NewOpenAIProvider("key")
+		if err != nil {
+			t.Fatal(err)
+		}
+		provider.WithBaseURL(server.URL + "/v1")
+		_, err = provider.RequestTools(context.Background(), []Message{{Role: RoleUser, Content: "?"}},
+			[]Tool{{Name: "look", Parameters: map[string]any{"type": "object"}}})
+		server.Close()
+		if errors.Is(err, ErrToolsNotSupported) != test.unsupported {
+			t.Errorf("unexpected error for %s: %v", test.body, err)
+		}
+	}
+}
+
+func TestOpenAIProviderTemperature(t *testing.T) {
+	tests := []struct {
+		temperature float32
+		max         float64
+	}{
+		// 0 must not be omitted from the request
+		{temperature: 0, max: 1e-30},
+		{temperature: 0.7, max: 0.71},
+	}
+	for _, test := range tests {
+		var body map[string]any
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Error(err)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+		}))
+		provider, err := NewOpenAIProvider("key")
+		if err != nil {
+			t.Fatal(err)
+		}
+		provider.WithBaseURL(server.URL + "/v1").WithTemperature(test.temperature)
+		_, err = provider.Request(context.Background(), "system", "prompt")
+		server.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		temperature, sent := body["temperature"].(float64)
+		if !sent || temperature <= 0 || temperature > test.max {
+			t.Errorf("WithTemperature(%v) sent %v", test.temperature, body["temperature"])
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package goreact
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// ParameterType is the JSON schema type of a parameter.
+type ParameterType string
+
+const (
+	TypeString  ParameterType = "string"
+	TypeInteger ParameterType = "integer"
+	TypeNumber  ParameterType = "number"
+	TypeBoolean ParameterType = "boolean"
+	TypeArray   ParameterType = "array"
+	TypeObject  ParameterType = "object"
+)
+
+// Parameter is a named and typed argument of a command.
+type Parameter struct {
+	Name        string
+	Type        ParameterType
+	Description string
+	Required    bool
+	// Enum restricts the values of a string parameter.
+	Enum []string
+	// Items is the type of the elements of an array parameter.
+	Items ParameterType
+	// Properties are the fields of an object parameter or of the
+	// objects of an array parameter. Objects without properties
+	// accept any fields.
+	Properties []Parameter
+}
+
+// Arguments are the validated arguments of a typed command. Integers
+// are stored as int64, numbers as float64.
+type Arguments map[string]any
+
+// String returns the string argument or "" when it was not given.
+func (a Arguments) String(name string) string {
+	s, _ := a[name].(string)
+	return s
+}
+
+// Int returns the integer argument or 0 when it was not given.
+func (a Arguments) Int(name string) int64 {
+	i, _ := a[name].(int64)
+	return i
+}
+
+// Float returns the number argument or 0 when it was not given.
+func (a Arguments) Float(name string) float64 {
+	switch v := a[name].(type) {
+	case float64:
+		return v
+	case int64:
+		return float64(v)
+	}
+	return 0
+}
+
+// Bool returns the boolean argument or false when it was not given.
+func (a Arguments) Bool(name string) bool {
+	b, _ := a[name].(bool)
+	return b
+}
+
+// typed returns true when the command has named parameters.
+func (c Command) typed() bool {
+	return len(c.Parameters) > 0 || c.TypedFunc != nil
+}
+
+// arguments parses and validates the argument of a typed command which
+// is a JSON object with the parameters. When the command has only one
+// required string parameter a plain text argument is accepted as well.
+func (c Command) arguments(argument string) (Arguments, error) {
+	argument = strings.TrimSpace(argument)
+	var raw map[string]any
+	if argument == "" {
+		raw = map[string]any{}
+	} else if err := json.Unmarshal([]byte(argument), &raw); err != nil {
+		parameter, ok := c.singleStringParameter()
+		if !ok {
+			return nil, fmt.Errorf("the argument must be a JSON object like %s", c.signature())
+		}
+		raw = map[string]any{parameter.Name: argument}
+	}
+
+	args, err := validateObject(c.Parameters, raw)
+	if err != nil {
+		return nil, err
+	}
+	return Arguments(args), nil
+}
+
+// validateObject validates the fields of an object with the parameters.
+func validateObject(parameters []Parameter, raw map[string]any) (map[string]any, error) {
+	args := make(map[string]any)
+	for name, value := range raw {
+		i := slices.IndexFunc(parameters, func(p Parameter) bool { return p.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown parameter %q, the parameters are %s", name, signature(parameters))
+		}
+		if value == nil {
+			continue
+		}
+		v, err := parameters[i].validate(value)
+		if err != nil {
+			return nil, err
+		}
+		args[name] = v
+	}
+	for _, parameter := range parameters {
+		if _, ok := args[parameter.Name]; parameter.Required && !ok {
+			return nil, fmt.Errorf("the required parameter %q is missing", parameter.Name)
+		}
+	}
+	return args, nil
+}
+
+func (c Command) singleStringParameter() (Parameter, bool) {
+	var required []Parameter
+	for _, parameter := range c.Parameters {
+		if parameter.Required {
+			required = append(required, parameter)
+		}
+	}
+	if len(required) == 1 && (required[0].Type == TypeString || required[0].Type == "") {
+		return required[0], true
+	}
+	return Parameter{}, false
+}
+
+// validate checks the type of the value decoded from JSON.
+func (p Parameter) validate(value any) (any, error) {
+	invalid := func() error {
+		return fmt.Errorf("the parameter %q must be of type %s, got %v", p.Name, p.Type, value)
+	}
+	switch p.Type {
+	case TypeString, "":
+		s, ok := value.(string)
+		if !ok {
+			return nil, invalid()
+		}
+		if len(p.Enum) > 0 && !slices.Contains(p.Enum, s) {
+			return nil, fmt.Errorf("the parameter %q must be one of %s, got %q",
+				p.Name, strings.Join(p.Enum, ", "), s)
+		}
+		return s, nil
+	case TypeInteger:
+		f, ok := value.(float64)
+		if !ok || f != math.Trunc(f) {
+			return nil, invalid()
+		}
+		return int64(f), nil
+	case TypeNumber:
+		f, ok := value.(float64)
+		if !ok {
+			return nil, invalid()
+		}
+		return f, nil
+	case TypeBoolean:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, invalid()
+		}
+		return b, nil
+	case TypeArray:
+		items, ok := value.([]any)
+		if !ok {
+			return nil, invalid()
+		}
+		if p.Items == "" {
+			return items, nil
+		}
+		item := Parameter{Name: p.Name, Type: p.Items, Enum: p.Enum, Properties: p.Properties}
+		for i := range items {
+			v, err := item.validate(items[i])
+			if err != nil {
+				return nil, err
+			}
+			items[i] = v
+		}
+		return items, nil
+	case TypeObject:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, invalid()
+		}
+		if len(p.Properties) == 0 {
+			return object, nil
+		}
+		fields, err := validateObject(p.Properties, object)
+		if err != nil {
+			return nil, fmt.Errorf("the parameter %q is invalid: %w", p.Name, err)
+		}
+		return fields, nil
+	}
+	return nil, fmt.Errorf("the parameter %q has the unknown type %s", p.Name, p.Type)
+}
+
+// signature describes the JSON object of the arguments for the prompt,
+// like {"query": string, "limit"?: integer}.
+func (c Command) signature() string {
+	return signature(c.Parameters)
+}
+
+func signature(parameters []Parameter) string {
+	var fields []string
+	for _, parameter := range parameters {
+		name := fmt.Sprintf("%q", parameter.Name)
+		if !parameter.Required {
+			name += "?"
+		}
+		fields = append(fields, name+": "+parameter.typeName())
+	}
+	return "{" + strings.Join(fields, ", ") + "}"
+}
+
+func (p Parameter) typeName() string {
+	if len(p.Enum) > 0 {
+		var values []string
+		for _, value := range p.Enum {
+			values = append(values, fmt.Sprintf("%q", value))
+		}
+		if p.Type == TypeArray {
+			return "array of " + strings.Join(values, "|")
+		}
+		return strings.Join(values, "|")
+	}
+	if p.Type == TypeArray && p.Items != "" {
+		if p.Items == TypeObject && len(p.Properties) > 0 {
+			return "array of " + signature(p.Properties)
+		}
+		return "array of " + string(p.Items)
+	}
+	if p.Type == TypeObject && len(p.Properties) > 0 {
+		return signature(p.Properties)
+	}
+	if p.Type == "" {
+		return string(TypeString)
+	}
+	return string(p.Type)
+}
+
+// parameterDescriptions returns the descriptions of the parameters for
+// the prompt.
+func (c Command) parameterDescriptions() string {
+	var descriptions []string
+	for _, parameter := range c.Parameters {
+		if parameter.Description != "" {
+			descriptions = append(descriptions, parameter.Name+": "+parameter.Description)
+		}
+	}
+	return strings.Join(descriptions, "; ")
+}
+
+// schema returns the JSON schema of the parameters for tool calling.
+func (c Command) schema() map[string]any {
+	return objectSchema(c.Parameters)
+}
+
+func objectSchema(parameters []Parameter) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+	for _, parameter := range parameters {
+		properties[parameter.Name] = parameter.schema()
+		if parameter.Required {
+			required = append(required, parameter.Name)
+		}
+	}
+	return map[string]any{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+func (p Parameter) schema() map[string]any {
+	typ := p.Type
+	if typ == "" {
+		typ = TypeString
+	}
+	schema := map[string]any{"type": typ}
+	if p.Description != "" {
+		schema["description"] = p.Description
+	}
+	enum := p.Enum
+	if typ == TypeArray {
+		items := map[string]any{}
+		if p.Items == TypeObject && len(p.Properties) > 0 {
+			items = objectSchema(p.Properties)
+		} else if p.Items != "" {
+			items["type"] = p.Items
+		}
+		if len(enum) > 0 {
+			items["enum"] = enum
+			enum = nil
+		}
+		schema["items"] = items
+	}
+	if typ == TypeObject && len(p.Properties) > 0 {
+		object := objectSchema(p.Properties)
+		schema["properties"] = object["properties"]
+		schema["required"] = object["required"]
+	}
+	if len(enum) > 0 {
+		schema["enum"] = enum
+	}
+	return schema
+}
@@ -0,0 +1,290 @@
+package goreact
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ActionParser parses the action in a response of the LLM. Format
+// writes an action in the same format so that the examples in the
+// prompt always match the parser.
+type ActionParser interface {
+	// Parse parses the text after the ACTION: marker.
+	Parse(text string) (Action, error)
+	// Format returns the action as the LLM has to write it.
+	Format(action Action) string
+}
+
+// WithActionParser sets the parser for the actions in the responses of
+// the LLM. The examples in the main prompt are written in its format.
+// The default is AutoActionParser.
+func (r *React) WithActionParser(parser ActionParser) *React {
+	r.parser = parser
+	return r
+}
+
+// protocolParser is implemented by the parsers which cut the argument
+// at the markers of the protocol.
+type protocolParser interface {
+	withProtocol(protocol Protocol) ActionParser
+}
+
+// actionParser returns the parser with the protocol of the loop.
+func (r *React) actionParser() ActionParser {
+	if parser, ok := r.parser.(protocolParser); ok {
+		return parser.withProtocol(r.protocol)
+	}
+	return r.parser
+}
+
+// PlainActionParser parses actions of the form
+//
+//	calculate 7*77
+//
+// where the first word is the command and the rest is the argument.
+// The argument can span multiple lines and ends at the StopAction of
+// the protocol, like STOP_ACTION. It can also be a fenced code block
+// or a heredoc which are passed to the command without the fences or
+// delimiters:
+//
+//	writefile ```go
+//	package main
+//	```
+//
+//	run <<EOF
+//	echo "hello"
+//	EOF
+type PlainActionParser struct {
+	// Protocol defines the markers at which the argument ends. The
+	// zero value uses DefaultProtocol; React sets the protocol of
+	// WithProtocol.
+	Protocol Protocol
+}
+
+func (p PlainActionParser) Parse(text string) (Action, error) {
+	protocol := p.protocol()
+	text = strings.TrimLeft(text, " \t\r\n")
+	command, rest := text, ""
+	if i := strings.IndexAny(text, " \t\r\n"); i >= 0 {
+		command, rest = text[:i], strings.TrimLeft(text[i:], " \t")
+	}
+	if command == "" || command == protocol.StopAction {
+		return Action{}, fmt.Errorf("no command in action: %q", text)
+	}
+	if argument, ok := fencedBlock(rest, protocol.StopAction); ok {
+		return Action{Command: command, Argument: argument}, nil
+	}
+	if argument, ok := heredoc(rest, protocol.StopAction); ok {
+		return Action{Command: command, Argument: argument}, nil
+	}
+	argument := rest
+	for _, marker := range []string{protocol.StopAction, "\n" + protocol.Observation} {
+		if i := strings.Index(argument, marker); i >= 0 {
+			argument = argument[:i]
+		}
+	}
+	argument = strings.TrimRight(argument, " \t\r\n")
+	if !strings.Contains(argument, "\n") {
+		argument = strings.Trim(argument, " \"")
+	}
+	return Action{Command: command, Argument: argument}, nil
+}
+
+// protocol returns the protocol of the parser or DefaultProtocol.
+func (p PlainActionParser) protocol() Protocol {
+	if p.Protocol.StopAction == "" {
+		return DefaultProtocol
+	}
+	return p.Protocol
+}
+
+// withProtocol returns the parser with the protocol unless it has its
+// own.
+func (p PlainActionParser) withProtocol(protocol Protocol) ActionParser {
+	if p.Protocol.StopAction == "" {
+		p.Protocol = protocol
+	}
+	return p
+}
+
+// fencedBlock returns the content of the fenced code block at the
+// start of text. A block without closing fence ends at stop.
+func fencedBlock(text, stop string) (string, bool) {
+	if !strings.HasPrefix(text, "```") {
+		return "", false
+	}
+	fence := text[:len(text)-len(strings.TrimLeft(text, "`"))]
+	// skip the info string like go or json
+	_, body, found := strings.Cut(text, "\n")
+	if !found {
+		return "", false
+	}
+	if strings.HasPrefix(body, fence) {
+		return "", true
+	}
+	i := strings.Index(body, "\n"+fence)
+	if i < 0 {
+		// the closing fence is missing, e.g. cut by a stop sequence
+		body, _, _ = strings.Cut(body, stop)
+		return strings.TrimRight(body, "\n"), true
+	}
+	return body[:i], true
+}
+
+// heredoc returns the content of a heredoc like <<EOF or <<'EOF' at
+// the start of text. The content ends at the line which only contains
+// the delimiter or at stop. Like in the shell <<-EOF strips leading
+// tabs.
+func heredoc(text, stop string) (string, bool) {
+	if !strings.HasPrefix(text, "<<") {
+		return "", false
+	}
+	line, body, found := strings.Cut(text[2:], "\n")
+	stripTabs := strings.HasPrefix(line, "-")
+	delimiter := strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "-")), "'\"")
+	if !found || delimiter == "" || strings.ContainsAny(delimiter, " \t") {
+		return "", false
+	}
+	end := -1
+	lines := strings.Split(body, "\n")
+	for i, l := range lines {
+		if strings.TrimSpace(l) == delimiter {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		body, _, _ = strings.Cut(body, stop)
+		lines = strings.Split(strings.TrimRight(body, "\n"), "\n")
+		end = len(lines)
+	}
+	lines = lines[:end]
+	if stripTabs {
+		for i := range lines {
+			lines[i] = strings.TrimLeft(lines[i], "\t")
+		}
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+func (PlainActionParser) Format(action Action) string {
+	return action.Command + " " + action.Argument
+}
+
+// JSONActionParser parses actions of the form
+//
+//	{"command": "calculate", "argument": "7*77"}
+//
+// The argument may also be given as "args" or "arguments". Arguments
+// which are no strings are passed to the command as JSON.
+type JSONActionParser struct{}
+
+func (JSONActionParser) Parse(text string) (Action, error) {
+	start := strings.Index(text, "{")
+	if start < 0 {
+		return Action{}, fmt.Errorf("no JSON object in action: %q", text)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.NewDecoder(strings.NewReader(text[start:])).Decode(&fields); err != nil {
+		return Action{}, fmt.Errorf("invalid JSON in action: %w", err)
+	}
+	var action Action
+	if err := json.Unmarshal(fields["command"], &action.Command); err != nil || action.Command == "" {
+		return Action{}, fmt.Errorf("no command in action: %q", text)
+	}
+	for _, key := range []string{"argument", "args", "arguments"} {
+		if raw, ok := fields[key]; ok {
+			if err := json.Unmarshal(raw, &action.Argument); err != nil {
+				action.Argument = strings.TrimSpace(string(raw))
+			}
+			break
+		}
+	}
+	return action, nil
+}
+
+func (JSONActionParser) Format(action Action) string {
+	data, _ := json.Marshal(struct {
+		Command  string `json:"command"`
+		Argument string `json:"argument"`
+	}{action.Command, action.Argument})
+	return string(data)
+}
+
+// XMLActionParser parses actions of the form
+//
+//	<command>calculate</command><argument>7*77</argument>
+//
+// The argument can contain any text including tags as it ends at the
+// last closing argument tag. Entities like &lt; and CDATA sections
+// are decoded.
+type XMLActionParser struct{}
+
+func (XMLActionParser) Parse(text string) (Action, error) {
+	command, ok := xmlElement(text, "command", false)
+	if !ok || command == "" {
+		return Action{}, fmt.Errorf("no <command> in action: %q", text)
+	}
+	argument, _ := xmlElement(text, "argument", true)
+	return Action{Command: command, Argument: argument}, nil
+}
+
+func (XMLActionParser) Format(action Action) string {
+	return "<command>" + action.Command + "</command><argument>" + action.Argument + "</argument>"
+}
+
+var xmlEntities = strings.NewReplacer("&lt;", "<", "&gt;", ">", "&quot;", "\"", "&apos;", "'", "&amp;", "&")
+
+// xmlElement returns the trimmed and decoded content of the element
+// with the tag. With last the content ends at the last closing tag.
+func xmlElement(text, tag string, last bool) (string, bool) {
+	start := strings.Index(text, "<"+tag+">")
+	if start < 0 {
+		return "", false
+	}
+	text = text[start+len(tag)+2:]
+	var end int
+	if last {
+		end = strings.LastIndex(text, "</"+tag+">")
+	} else {
+		end = strings.Index(text, "</"+tag+">")
+	}
+	if end < 0 {
+		return "", false
+	}
+	content := strings.TrimSpace(text[:end])
+	if strings.HasPrefix(content, "<![CDATA[") && strings.HasSuffix(content, "]]>") {
+		return content[len("<![CDATA[") : len(content)-len("]]>")], true
+	}
+	return xmlEntities.Replace(content), true
+}
+
+// AutoActionParser detects whether an action is written as JSON, XML
+// or in the plain format. The prompt shows the plain format.
+type AutoActionParser struct {
+	// Protocol is passed to the PlainActionParser.
+	Protocol Protocol
+}
+
+func (a AutoActionParser) Parse(text string) (Action, error) {
+	trimmed := strings.TrimSpace(text)
+	switch {
+	case strings.HasPrefix(trimmed, "{"):
+		return JSONActionParser{}.Parse(text)
+	case strings.HasPrefix(trimmed, "<"):
+		return XMLActionParser{}.Parse(text)
+	}
+	return PlainActionParser{Protocol: a.Protocol}.Parse(text)
+}
+
+func (a AutoActionParser) withProtocol(protocol Protocol) ActionParser {
+	if a.Protocol.StopAction == "" {
+		a.Protocol = protocol
+	}
+	return a
+}
+
+func (AutoActionParser) Format(action Action) string {
+	return PlainActionParser{}.Format(action)
+}
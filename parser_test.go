@@ -0,0 +1,188 @@
+package goreact
+
+import "testing"
+
+func TestPlainActionParser(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		command  string
+		argument string
+		err      bool
+	}{
+		{
+			name:     "single line",
+			response: "THOUGHT: I need to calculate.\nACTION: calculate 7*77",
+			command:  "calculate",
+			argument: "7*77",
+		},
+		{
+			name:     "quoted argument with stop action",
+			response: "ACTION: search \"goreact\" STOP_ACTION",
+			command:  "search",
+			argument: "goreact",
+		},
+		{
+			name:     "JSON argument",
+			response: "ACTION: calculate {\"expression\": \"sqrt(10)\"}\nOBSERVATION: 3.16",
+			command:  "calculate",
+			argument: "{\"expression\": \"sqrt(10)\"}",
+		},
+		{
+			name:     "multiple lines",
+			response: "ACTION: note first line\nsecond line\nSTOP_ACTION",
+			command:  "note",
+			argument: "first line\nsecond line",
+		},
+		{
+			name:     "fence with info string",
+			response: "ACTION: writefile ```go\npackage main\n\nfunc main() {}\n```\nSTOP_ACTION",
+			command:  "writefile",
+			argument: "package main\n\nfunc main() {}",
+		},
+		{
+			name:     "fence with JSON",
+			response: "ACTION: store ```json\n{\"key\": \"value\"}\n```",
+			command:  "store",
+			argument: "{\"key\": \"value\"}",
+		},
+		{
+			name:     "longer fence around a fence",
+			response: "ACTION: writefile ````markdown\n```go\nx := 1\n```\n````",
+			command:  "writefile",
+			argument: "```go\nx := 1\n```",
+		},
+		{
+			name:     "empty fence",
+			response: "ACTION: writefile ```\n```",
+			command:  "writefile",
+			argument: "",
+		},
+		{
+			name:     "missing closing fence cut by the stop sequence",
+			response: "ACTION: writefile ```go\npackage main\n",
+			command:  "writefile",
+			argument: "package main",
+		},
+		{
+			name:     "missing closing fence before stop action",
+			response: "ACTION: writefile ```go\npackage main\nSTOP_ACTION",
+			command:  "writefile",
+			argument: "package main",
+		},
+		{
+			name:     "argument containing the action marker",
+			response: "ACTION: writefile ```go\n// ACTION: calculate 1+1\nfmt.Println(\"ACTION: \")\n```",
+			command:  "writefile",
+			argument: "// ACTION: calculate 1+1\nfmt.Println(\"ACTION: \")",
+		},
+		{
+			name:     "heredoc",
+			response: "ACTION: run <<EOF\necho \"hello\"\nls -l\nEOF\nSTOP_ACTION",
+			command:  "run",
+			argument: "echo \"hello\"\nls -l",
+		},
+		{
+			name:     "heredoc with quoted delimiter",
+			response: "ACTION: run <<'END'\necho $HOME\nEND",
+			command:  "run",
+			argument: "echo $HOME",
+		},
+		{
+			name:     "heredoc with double quoted delimiter",
+			response: "ACTION: run <<\"END\"\necho $HOME\nEND",
+			command:  "run",
+			argument: "echo $HOME",
+		},
+		{
+			name:     "heredoc with stripped tabs",
+			response: "ACTION: run <<-EOF\n\tif true; then\n\t\techo yes\n\tfi\n\tEOF",
+			command:  "run",
+			argument: "if true; then\necho yes\nfi",
+		},
+		{
+			name:     "heredoc without delimiter cut by the stop sequence",
+			response: "ACTION: run <<EOF\necho \"hello\"\n",
+			command:  "run",
+			argument: "echo \"hello\"",
+		},
+		{
+			name:     "no heredoc",
+			response: "ACTION: calculate 1<<4",
+			command:  "calculate",
+			argument: "1<<4",
+		},
+		{
+			name:     "no command",
+			response: "ACTION: STOP_ACTION",
+			err:      true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			text, found := DefaultProtocol.action(test.response)
+			if !found {
+				t.Fatalf("no action in %q", test.response)
+			}
+			action, err := PlainActionParser{}.Parse(text)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", action)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if action.Command != test.command || action.Argument != test.argument {
+				t.Errorf("expected %q %q, got %q %q", test.command, test.argument,
+					action.Command, action.Argument)
+			}
+		})
+	}
+}
+
+func TestPlainActionParserWithoutProtocol(t *testing.T) {
+	// the parser cuts at the stop action itself when it gets the
+	// response without the protocol
+	tests := []struct {
+		text     string
+		argument string
+	}{
+		{"writefile ```go\npackage main\nSTOP_ACTION\nOBSERVATION: done", "package main"},
+		{"run <<EOF\nls\nSTOP_ACTION", "ls"},
+		{"note a\nb\nOBSERVATION: done", "a\nb"},
+	}
+	for _, test := range tests {
+		action, err := PlainActionParser{}.Parse(test.text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if action.Argument != test.argument {
+			t.Errorf("Parse(%q): expected argument %q, got %q", test.text, test.argument, action.Argument)
+		}
+	}
+}
+
+func TestAutoActionParser(t *testing.T) {
+	tests := []struct {
+		text     string
+		command  string
+		argument string
+	}{
+		{"calculate 7*77", "calculate", "7*77"},
+		{`{"command": "calculate", "argument": "7*77"}`, "calculate", "7*77"},
+		{`{"command": "calculate", "args": {"expression": "7*77"}}`, "calculate", `{"expression": "7*77"}`},
+		{"<command>writefile</command><argument><![CDATA[a < b]]></argument>", "writefile", "a < b"},
+		{"<command>run</command><argument>x &lt; <b>y</b></argument>", "run", "x < <b>y</b>"},
+	}
+	for _, test := range tests {
+		action, err := AutoActionParser{}.Parse(test.text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if action.Command != test.command || action.Argument != test.argument {
+			t.Errorf("Parse(%q) = %q %q", test.text, action.Command, action.Argument)
+		}
+	}
+}
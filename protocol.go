@@ -0,0 +1,178 @@
+package goreact
+
+import (
+	"context"
+	"strings"
+)
+
+// Protocol defines the markers of the text protocol between the ReAct
+// loop and the LLM. The markers can be changed, e.g. for prompts in
+// other languages; the prompts get the protocol as .Protocol.
+type Protocol struct {
+	Question    string
+	Thought     string
+	Action      string
+	Observation string
+	Answer      string
+	// StopAction ends the argument of an action.
+	StopAction string
+	// Empty is the response of the summarizer when nothing in the
+	// text is relevant.
+	Empty string
+	// Valid and Invalid are the responses of the verifier which
+	// accept or reject an answer. Invalid is followed by the reason.
+	Valid   string
+	Invalid string
+	// Stop are the stop sequences sent to the providers. When nil the
+	// generation stops at Observation and StopAction.
+	Stop []string
+}
+
+// DefaultProtocol is the protocol of the default prompts.
+var DefaultProtocol = Protocol{
+	Question:    "QUESTION:",
+	Thought:     "THOUGHT:",
+	Action:      "ACTION:",
+	Observation: "OBSERVATION:",
+	Answer:      "ANSWER:",
+	StopAction:  "STOP_ACTION",
+	Empty:       "EMPTY",
+	Valid:       "VALID",
+	Invalid:     "INVALID:",
+}
+
+// StopSequences returns the stop sequences for the providers.
+func (p Protocol) StopSequences() []string {
+	if p.Stop != nil {
+		return p.Stop
+	}
+	return []string{p.Observation, p.StopAction}
+}
+
+// WithProtocol sets the markers of the text protocol (default
+// DefaultProtocol). The prompts must use the same markers. Empty
+// markers keep the ones of DefaultProtocol.
+func (r *React) WithProtocol(protocol Protocol) *React {
+	r.protocol = protocol.withDefaults()
+	return r
+}
+
+// withDefaults sets the empty markers to the ones of DefaultProtocol.
+func (p Protocol) withDefaults() Protocol {
+	for _, marker := range []struct {
+		value    *string
+		fallback string
+	}{
+		{&p.Question, DefaultProtocol.Question},
+		{&p.Thought, DefaultProtocol.Thought},
+		{&p.Action, DefaultProtocol.Action},
+		{&p.Observation, DefaultProtocol.Observation},
+		{&p.Answer, DefaultProtocol.Answer},
+		{&p.StopAction, DefaultProtocol.StopAction},
+		{&p.Empty, DefaultProtocol.Empty},
+		{&p.Valid, DefaultProtocol.Valid},
+		{&p.Invalid, DefaultProtocol.Invalid},
+	} {
+		if *marker.value == "" {
+			*marker.value = marker.fallback
+		}
+	}
+	return p
+}
+
+type protocolKey struct{}
+
+// ContextWithProtocol returns a context which carries the protocol of
+// the request to the provider.
+func ContextWithProtocol(ctx context.Context, protocol Protocol) context.Context {
+	return context.WithValue(ctx, protocolKey{}, protocol)
+}
+
+// ProtocolFromContext returns the protocol of the request or
+// DefaultProtocol. Providers use its stop sequences unless configured
+// otherwise.
+func ProtocolFromContext(ctx context.Context) Protocol {
+	if protocol, ok := ctx.Value(protocolKey{}).(Protocol); ok {
+		return protocol
+	}
+	return DefaultProtocol
+}
+
+// stopSequences returns the configured stop sequences of a provider or
+// the ones of the protocol of the request.
+func stopSequences(ctx context.Context, configured []string) []string {
+	if configured != nil {
+		return configured
+	}
+	return ProtocolFromContext(ctx).StopSequences()
+}
+
+// isAnswer returns true when the response contains an answer which is
+// not part of the argument of an action.
+func (p Protocol) isAnswer(response string) bool {
+	answer := strings.Index(response, p.Answer)
+	action := strings.Index(response, p.Action)
+	return answer >= 0 && (action < 0 || answer < action)
+}
+
+// action returns the text of the first action in the response up to
+// the end of its argument.
+func (p Protocol) action(response string) (string, bool) {
+	// the argument of the action might contain the action marker as
+	// well, e.g. in source code, hence the first one counts
+	_, action, found := strings.Cut(response, p.Action)
+	if !found {
+		return "", false
+	}
+	action = strings.TrimLeft(action, " \t")
+	if i := strings.Index(action, "\n"+p.Observation); i >= 0 {
+		action = action[:i]
+	}
+	if i := strings.Index(action, p.StopAction); i >= 0 {
+		action = action[:i]
+	}
+	return action, true
+}
+
+// extractAnswer returns the text after the last answer marker.
+func (p Protocol) extractAnswer(text string) string {
+	if i := strings.LastIndex(text, p.Answer); i >= 0 {
+		text = text[i+len(p.Answer):]
+	}
+	return strings.TrimSpace(text)
+}
+
+// extractThought returns the text before the first action or answer
+// marker without the thought marker.
+func (p Protocol) extractThought(text string) string {
+	for _, marker := range []string{p.Action, p.Answer} {
+		if i := strings.Index(text, marker); i >= 0 {
+			text = text[:i]
+		}
+	}
+	if i := strings.LastIndex(text, p.Thought); i >= 0 {
+		text = text[i+len(p.Thought):]
+	}
+	return strings.TrimSpace(text)
+}
+
+// observation returns the message with the output of a command.
+func (r *React) observation(observation string) Message {
+	return Message{Role: RoleUser, Content: r.protocol.Observation + " " + observation}
+}
+
+func (r *React) isObservation(message Message) bool {
+	return message.Role == RoleUser && strings.HasPrefix(message.Content, r.protocol.Observation+" ")
+}
+
+// rejection returns the reason when the response of the verifier
+// rejects the answer. The marker is matched case-insensitively and
+// its colon is optional.
+func (p Protocol) rejection(response string) (string, bool) {
+	response = strings.TrimSpace(response)
+	marker := strings.TrimSuffix(p.Invalid, ":")
+	if len(response) < len(marker) || !strings.EqualFold(response[:len(marker)], marker) {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(response[len(marker):], ":")), true
+}
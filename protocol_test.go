@@ -0,0 +1,194 @@
+package goreact
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var germanProtocol = Protocol{
+	Question:    "FRAGE:",
+	Thought:     "GEDANKE:",
+	Action:      "AKTION:",
+	Observation: "BEOBACHTUNG:",
+	Answer:      "ANTWORT:",
+	StopAction:  "ENDE_AKTION",
+	Empty:       "LEER",
+	Valid:       "GÜLTIG",
+	Invalid:     "UNGÜLTIG:",
+}
+
+func TestPromptsUseProtocol(t *testing.T) {
+	r, err := NewReact(&scriptedProvider{}, map[string]Command{
+		"calculate": {Argument: "expression", Description: "calculates", Examples: []string{"1+1"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.WithProtocol(germanProtocol)
+	main, err := r.systemPrompt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prompts := map[string]string{"BasicReActPrompt": main}
+	for name, prompt := range map[string]string{
+		"PromptSummarize":   PromptSummarize,
+		"PromptToolCalling": PromptToolCalling,
+		"PromptVerify":      PromptVerify,
+		"PromptRepair":      PromptRepair,
+	} {
+		if prompts[name], err = r.renderPrompt(prompt, "response"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, prompt := range prompts {
+		for _, marker := range []string{"QUESTION", "THOUGHT", "ACTION", "OBSERVATION", "ANSWER", "EMPTY", "VALID"} {
+			if strings.Contains(prompt, marker) {
+				t.Errorf("%s contains the default marker %s", name, marker)
+			}
+		}
+	}
+	if !strings.Contains(prompts["PromptVerify"], `"UNGÜLTIG: "`) {
+		t.Errorf("PromptVerify does not ask for the invalid marker:\n%s", prompts["PromptVerify"])
+	}
+}
+
+func TestActionParserUsesProtocol(t *testing.T) {
+	tests := []struct {
+		text     string
+		argument string
+	}{
+		{"rechne 1+1 ENDE_AKTION", "1+1"},
+		{"notiere a\nb\nBEOBACHTUNG: erfunden", "a\nb"},
+		{"schreibe ```go\npackage main\nENDE_AKTION", "package main"},
+		{"starte <<EOF\nls\nENDE_AKTION", "ls"},
+		// the default markers are part of the argument
+		{"rechne 1+1 STOP_ACTION", "1+1 STOP_ACTION"},
+	}
+	r, err := NewReact(&scriptedProvider{}, map[string]Command{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.WithProtocol(germanProtocol)
+	for _, parser := range []ActionParser{PlainActionParser{}, AutoActionParser{}} {
+		r.WithActionParser(parser)
+		for _, test := range tests {
+			action, err := r.actionParser().Parse(test.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if action.Argument != test.argument {
+				t.Errorf("%T: Parse(%q) returned argument %q, expected %q",
+					parser, test.text, action.Argument, test.argument)
+			}
+		}
+	}
+	if _, err := (PlainActionParser{Protocol: germanProtocol}).Parse("ENDE_AKTION"); err == nil {
+		t.Error("expected an error for an action without command")
+	}
+	// a parser with its own protocol keeps it
+	own := PlainActionParser{Protocol: DefaultProtocol}
+	if parser := own.withProtocol(germanProtocol).(PlainActionParser); parser.Protocol.StopAction != "STOP_ACTION" {
+		t.Errorf("expected the protocol of the parser, got %+v", parser.Protocol)
+	}
+}
+
+func TestProtocolRejection(t *testing.T) {
+	tests := []struct {
+		protocol Protocol
+		response string
+		reason   string
+		rejected bool
+	}{
+		{DefaultProtocol, "VALID", "", false},
+		{DefaultProtocol, "INVALID: no coin was observed", "no coin was observed", true},
+		{DefaultProtocol, " invalid no coin was observed\n", "no coin was observed", true},
+		{DefaultProtocol, "INVALID", "", true},
+		{germanProtocol, "UNGÜLTIG: keine Münze", "keine Münze", true},
+		{germanProtocol, "INVALID: no coin", "", false},
+		{germanProtocol, "GÜLTIG", "", false},
+	}
+	for _, test := range tests {
+		reason, rejected := test.protocol.rejection(test.response)
+		if reason != test.reason || rejected != test.rejected {
+			t.Errorf("rejection(%q) = %q, %v, expected %q, %v",
+				test.response, reason, rejected, test.reason, test.rejected)
+		}
+	}
+}
+
+func TestProtocolMarkersWithoutSpace(t *testing.T) {
+	tests := []struct {
+		response string
+		answer   bool
+		action   string
+	}{
+		{response: "ANSWER: 42", answer: true},
+		{response: "ANSWER:42", answer: true},
+		{response: "THOUGHT:done\nANSWER:\t42", answer: true},
+		{response: "ACTION:look north", action: "look north"},
+		{response: "ACTION:  look north", action: "look north"},
+		{response: "ACTION:look ANSWER:42", action: "look ANSWER:42"},
+	}
+	for _, test := range tests {
+		if answer := DefaultProtocol.isAnswer(test.response); answer != test.answer {
+			t.Errorf("isAnswer(%q) = %v", test.response, answer)
+		}
+		if test.answer && DefaultProtocol.extractAnswer(test.response) != "42" {
+			t.Errorf("extractAnswer(%q) = %q", test.response, DefaultProtocol.extractAnswer(test.response))
+		}
+		if action, _ := DefaultProtocol.action(test.response); action != test.action {
+			t.Errorf("action(%q) = %q, expected %q", test.response, action, test.action)
+		}
+	}
+
+	// no repair is requested for an answer without space
+	var arguments []string
+	llm := &scriptedProvider{responses: []string{"THOUGHT:I look.\nACTION:look north", "ANSWER:42"}}
+	r, err := NewReact(llm, lookCommands(&arguments))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := r.QuestionResult(context.Background(), "How many coins are there?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Answer != "42" || result.LLMCalls != 2 || fmt.Sprint(arguments) != "[north]" {
+		t.Errorf("unexpected result %+v with looks %v", result, arguments)
+	}
+}
+
+func TestWithProtocolDefaults(t *testing.T) {
+	r, err := NewReact(&scriptedProvider{}, map[string]Command{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.WithProtocol(Protocol{Answer: "ANTWORT:"})
+	if r.protocol.Answer != "ANTWORT:" || r.protocol.Invalid != DefaultProtocol.Invalid ||
+		r.protocol.StopAction != DefaultProtocol.StopAction || r.protocol.Stop != nil {
+		t.Errorf("unexpected protocol %+v", r.protocol)
+	}
+}
+
+func TestVerificationUsesProtocol(t *testing.T) {
+	llm := &scriptedProvider{responses: []string{
+		"GEDANKE: Ich rate.\nANTWORT: 3",
+		"UNGÜLTIG: keine Beobachtung",
+		"GEDANKE: Ich rate noch einmal.\nANTWORT: 4",
+		"GÜLTIG",
+	}}
+	r, err := NewReact(llm, map[string]Command{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.WithProtocol(germanProtocol).WithVerificationProvider(llm)
+	result, err := r.QuestionResult(context.Background(), "Wie viele Münzen?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Answer != "4" || result.VerificationCalls != 2 {
+		t.Errorf("expected the second answer after 2 verifications, got %q after %d",
+			result.Answer, result.VerificationCalls)
+	}
+}
@@ -1,8 +1,13 @@
 package goreact
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"sort"
 	"strings"
+	"text/template"
 	"time"
 )
 
@@ -11,176 +16,338 @@ type Command struct {
 	Argument    string
 	Description string
 	Func        func(string) (string, error)
+	// ContextFunc is used instead of Func when set. It receives the
+	// context of the question so that long running commands can be
+	// cancelled.
+	ContextFunc func(context.Context, string) (string, error)
+	// Parameters are the named and typed arguments of the command. The
+	// argument is then a JSON object which is validated before
+	// TypedFunc is called.
+	Parameters []Parameter
+	// TypedFunc is called with the validated arguments when the
+	// command has Parameters. Without TypedFunc, Func or ContextFunc
+	// get the validated JSON object as argument.
+	TypedFunc func(context.Context, Arguments) (string, error)
+	// Examples are example arguments of the command which are shown in
+	// the main prompt.
+	Examples []string
+}
+
+// call runs the function of the command. TypedFunc gets the validated
+// arguments, Func and ContextFunc get the argument as it is, which is
+// the JSON object of the arguments for commands with Parameters.
+func (c Command) call(ctx context.Context, argument string, args Arguments) (string, error) {
+	if c.TypedFunc != nil {
+		return c.TypedFunc(ctx, args)
+	}
+	if c.ContextFunc != nil {
+		return c.ContextFunc(ctx, argument)
+	}
+	if c.Func == nil {
+		return "", fmt.Errorf("command %s has no function", c.Name)
+	}
+	return c.Func(argument)
 }
 
 type React struct {
-	llm        LLMProvider
-	commands   map[string]Command
-	mainPrompt string
+	llm                LLMProvider
+	commands           map[string]Command
+	mainPrompt         string
+	maxSteps           int
+	maxDuration        time.Duration
+	maxTokens          int
+	finalAnswerOnLimit bool
+	logger             *slog.Logger
+	observers          []Observer
+	actionHooks        []ActionHook
+	toolCalling        bool
+	retry              bool
+	summarizer         LLMProvider
+	verifier           LLMProvider
+	pricing            Pricing
+	tokenizer          Tokenizer
+	limits             ContextLimits
+	repairAttempts     int
+	repairFallback     RepairFallback
+	parser             ActionParser
+	promptTemplate     *template.Template
+	promptErr          error
+	promptVars         map[string]any
+	clock              func() time.Time
+	protocol           Protocol
+}
+
+// chatProvider returns the LLM provider as ChatProvider. Providers
+// which only implement LLMProvider are wrapped by an adapter.
+func (r *React) chatProvider() ChatProvider {
+	return asChatProvider(r.retrying(r.llm))
 }
 
 func NewReact(llmProvider LLMProvider, commands map[string]Command) (*React, error) {
 	if commands == nil {
 		return nil, fmt.Errorf("commands cannot be nil")
 	}
-	return &React{
-		llm:        llmProvider,
-		commands:   commands,
-		mainPrompt: BasicReActPrompt,
-	}, nil
+	tokenizer, err := tokenizerFor(llmProvider)
+	if err != nil {
+		return nil, err
+	}
+	r := &React{
+		llm:            llmProvider,
+		commands:       commands,
+		maxSteps:       DefaultMaxSteps,
+		logger:         newDiscardLogger(),
+		tokenizer:      tokenizer,
+		limits:         contextLimits(llmProvider),
+		repairAttempts: DefaultRepairAttempts,
+		parser:         AutoActionParser{},
+		clock:          time.Now,
+		protocol:       DefaultProtocol,
+		retry:          true,
+	}
+	r.WithPromptTemplate(BasicReActPrompt)
+	if r.promptErr != nil {
+		return nil, r.promptErr
+	}
+	return r, nil
 }
 
-func (r *React) WithMainPrompt(prompt string) *React {
-	r.mainPrompt = prompt
-	return r
+func (r *React) Question(question string) (string, error) {
+	return r.QuestionContext(context.Background(), question)
 }
 
-func (r *React) Question(question string) (string, error) {
-	fmt.Println("QUESTION:", question)
-	fullprompt, action, answer, err := r.getInitialThoughtAndAction(r.mainPrompt, question)
-	if err != nil {
-		return "", err
-	}
-	if answer != "" {
-		// Looks like the LLM very often answers the question directly.
-		// We don't really want that, it should use the commands at least
-		// once. Hence I added an instruction in the prompt to run
-		// at least one cycle...
-		fmt.Printf("Too easy. Immediately answering: %s\n", answer)
-		return answer, nil
-	}
+// QuestionContext is like Question but passes ctx to every LLM request
+// and every command. The loop stops as soon as ctx is cancelled.
+//
+// When one of the configured limits is hit a *LimitError is returned.
+// With WithFinalAnswerOnLimit the best-effort answer is returned
+// together with that error.
+func (r *React) QuestionContext(ctx context.Context, question string) (string, error) {
+	result, err := r.QuestionResult(ctx, question)
+	return result.Answer, err
+}
 
-	fmt.Println(action)
-	observation, err := r.executeAction(action)
-	if err != nil {
-		return "", err
+// QuestionResult answers the question like QuestionContext but returns
+// the full trace of the steps which led to the answer. The result is
+// never nil, also in case of an error it contains the steps executed
+// so far.
+func (r *React) QuestionResult(ctx context.Context, question string) (*Result, error) {
+	qr := r.newRun(ctx, question)
+	defer qr.cancel()
+
+	err := r.answer(qr, question)
+	qr.result.Duration = time.Since(qr.start)
+	return qr.result, err
+}
+
+func (r *React) answer(qr *run, question string) error {
+	r.logger.InfoContext(qr.ctx, "question", "question", question)
+	r.emit(qr, Event{Type: EventQuestion})
+	if _, ok := r.llm.(ToolProvider); ok && r.toolCalling {
+		err := r.toolLoop(qr, r.retrying(r.llm).(ToolProvider), question)
+		if !errors.Is(err, ErrToolsNotSupported) {
+			return err
+		}
+		r.logger.InfoContext(qr.ctx, "tool calling not supported, using text protocol")
 	}
+	return r.loop(qr, question)
+}
 
-	// The observation of the action might be too long to serve
-	// as input for the next step. Hence we compress it to 512
-	// characters. Doing that by letting the LLM summarize the
-	// observation based on relevant information with regards
-	// to the question.
-	observation, err = r.createSummaryOfSummaries(question, observation, 512)
+func (r *React) loop(qr *run, question string) error {
+	chat := r.chatProvider()
+	// The system prompt and the question are the stable prefix of the
+	// conversation. The thoughts and actions of the LLM are appended
+	// as assistant messages and the observations as user messages.
+	system, err := r.systemPrompt()
 	if err != nil {
-		return "", fmt.Errorf("unable to compress observation: %v", err)
+		return err
+	}
+	history := []Message{
+		{Role: RoleSystem, Content: system},
+		{Role: RoleUser, Content: r.protocol.Question + " " + question},
 	}
-
 	for {
-		fmt.Println("OBSERVATION: ", observation)
-		if strings.Contains(observation, "ANSWER:") {
-			fmt.Println("ANSWER:", observation)
-			return observation, nil
-		}
-		fullprompt = fullprompt + "\n" + "OBSERVATION: " + observation
-
-		fullprompt, action, err = r.getThoughtAndAction(fullprompt)
-		if strings.Contains(fullprompt, "ANSWER:") {
-			return fullprompt, nil
+		if err := r.step(qr); err != nil {
+			return r.stop(qr, err, history)
 		}
+		step := qr.newStep()
+		started := time.Now()
+		var thought, action, response string
+		var err error
+		history, thought, action, response, err = r.getThoughtAndAction(qr, chat, history)
+		r.setThought(qr, step, thought, time.Since(started))
 		if err != nil {
-			return "", err
+			return r.stop(qr, err, history)
+		}
+		if action == "" {
+			if len(qr.result.Steps) == 1 {
+				// Looks like the LLM very often answers the question directly.
+				// We don't really want that, it should use the commands at least
+				// once. Hence I added an instruction in the prompt to run
+				// at least one cycle...
+				r.logger.InfoContext(qr.ctx, "answer without action", "step", qr.steps)
+			}
+			answer := r.protocol.extractAnswer(response)
+			reason, err := r.verify(qr, answer, history)
+			if err != nil {
+				return r.stop(qr, err, history)
+			}
+			if reason == "" {
+				r.setAnswer(qr, answer)
+				return nil
+			}
+			history = append(history, r.observation(rejectedAnswer(reason)))
+			continue
 		}
 
-		observation, err = r.executeAction(action)
-		if err != nil && observation == "" {
+		observation, err := r.executeAction(qr, step, action)
+		if err != nil && (observation == "" || qr.ctx.Err() != nil) {
 			// observation might contain the error of the application
 			// which can be helpful to understand what went wrong for
 			// the LLM. Hence we only return "hard" errors which has
 			// no observation to abort the conversation.
-			return "", err
+			return r.stop(qr, r.checkError(qr, err), history)
 		}
 
-		observation, err = r.createSummaryOfSummaries(question, observation, 512)
+		observation, err = r.compress(qr, step, question, observation)
 		if err != nil {
-			return "", fmt.Errorf("unable to compress observation: %v", err)
+			return r.stop(qr, err, history)
 		}
-	}
-}
 
-func (r *React) getInitialThoughtAndAction(systemPrompt, question string) (string, string, string, error) {
-	systemPrompt = fmt.Sprintf(systemPrompt, r.commandDescriptions())
-	fullPrompt, err := r.llm.Request(systemPrompt, "QUESTION: "+question+"\n")
-	if err != nil {
-		return "", "", "", err
+		r.logger.InfoContext(qr.ctx, "observation", "step", qr.steps, "observation", observation)
+		history = append(history, r.observation(observation))
 	}
+}
 
-	if strings.Contains(fullPrompt, "ANSWER: ") {
-		return "", "", strings.Split(fullPrompt, "ANSWER:")[1], nil
-	}
-
-	fullPrompt = "QUESTION: " + question + "\n" + fullPrompt
-
-	for _, line := range strings.Split(fullPrompt, "\n") {
-		if strings.HasPrefix(line, "THOUGHT: ") {
-			fmt.Println(line)
-		}
-		if strings.HasPrefix(line, "ACTION: ") {
-			fmt.Println(line)
-			return fullPrompt, strings.Replace(line, "ACTION: ", "", 1), "", nil
+// stop ends the loop with err. When err is caused by a limit the LLM
+// gets the chance to give a final answer based on the history.
+func (r *React) stop(qr *run, err error, history []Message) error {
+	var limitErr *LimitError
+	if errors.As(err, &limitErr) {
+		var answer string
+		answer, err = r.finalAnswer(qr, limitErr, history)
+		if answer != "" {
+			r.setAnswer(qr, answer)
 		}
 	}
+	return err
+}
 
-	return "", "", "", fmt.Errorf("no action found: %s", fullPrompt)
+func (r *React) setThought(qr *run, step *Step, thought string, d time.Duration) {
+	step.Thought = thought
+	step.ThoughtDuration = d
+	if thought != "" {
+		r.emit(qr, Event{Type: EventThought, Thought: thought, Duration: d})
+	}
 }
 
-func (r *React) getThoughtAndAction(history string) (string, string, error) {
-	prompt := fmt.Sprintf("%s\nTHOUGHT: ", history)
-	system := fmt.Sprintf(BasicReActPrompt, r.commandDescriptions())
+func (r *React) setAnswer(qr *run, answer string) {
+	qr.result.Answer = answer
+	r.logger.InfoContext(qr.ctx, "answer", "step", qr.steps, "answer", answer)
+	r.emit(qr, Event{Type: EventAnswer, Answer: answer})
+}
 
-	fmt.Printf("[context size: around %d tokens]\n", len(prompt)/4)
-	if len(prompt)/4 > 14000 {
-		fmt.Println("WARNING: context size is too large, truncating...")
-		// remove all lines starting with OBSERVATION:
-		prompt = compressPromptContext(prompt)
+// getThoughtAndAction sends the history to the LLM and returns the
+// history extended by the response, the thought, the action, and the
+// response itself. When the LLM answered the question the action is
+// empty.
+func (r *React) getThoughtAndAction(qr *run, chat ChatProvider, history []Message) ([]Message, string, string, string, error) {
+	messages := history
+	tokens := r.countMessageTokens(messages)
+	r.logger.DebugContext(qr.ctx, "context size", "step", qr.steps, "tokens", tokens)
+	if tokens > r.limits.ContextWindow {
+		r.logger.WarnContext(qr.ctx, "context size is too large, truncating",
+			"step", qr.steps, "tokens", tokens)
+		// remove all but the last observation
+		messages = r.compressPromptContext(qr, messages)
 	}
-	thought, err := r.llm.Request(system, prompt)
+	response, err := r.chat(qr, chat, messages)
 	if err != nil {
-		return "", "", err
+		return history, "", "", "", err
 	}
-
-	thought = strings.Trim(thought, "\n")
+	response = strings.Trim(response, "\n")
+	history = append(history, Message{Role: RoleAssistant, Content: response})
 
 	// check if there is an answer
-	if strings.Contains(thought, "ANSWER: ") {
-		return thought, "", nil
+	if r.protocol.isAnswer(response) {
+		return history, r.protocol.extractThought(response), "", response, nil
 	}
 
 	// THOUGHTS can be multilines
-	fmt.Println("THOUGHT: " + strings.Split(thought, "\n")[0])
+	thought := r.protocol.extractThought(response)
+	r.logger.InfoContext(qr.ctx, "thought", "step", qr.steps, "thought", thought)
 
 	// parse ACTION: from result
-	var result []string
-	for {
-		result = strings.Split(thought, "ACTION: ")
-		if len(result) < 2 {
-			// there is no ACTION: retry
-			prompt := fmt.Sprintf("%s\nACTION: ", history+"\nnTHOUGHT: "+thought)
-			thought, err = r.llm.Request(system, prompt)
-			if err != nil {
-				return "", "", err
-			}
-			thought = strings.Trim(thought, "\n")
-		} else {
+	var action string
+	for attempt := 0; ; attempt++ {
+		var found bool
+		if action, found = r.protocol.action(response); found {
 			break
 		}
+		if attempt >= r.repairAttempts {
+			response, err = r.noAction(qr, response)
+			return history, thought, "", response, err
+		}
+		// there is no ACTION: ask for a repaired response (counts
+		// as a step so that the retries are bounded by the step
+		// limit as well)
+		r.logger.InfoContext(qr.ctx, "no action in response, repairing", "step", qr.steps,
+			"attempt", attempt+1)
+		if err := r.step(qr); err != nil {
+			return history, thought, "", "", err
+		}
+		repair, err := r.repairMessage(response)
+		if err != nil {
+			return history, thought, "", "", err
+		}
+		history = append(history, repair)
+		response, err = r.chat(qr, chat, history)
+		if err != nil {
+			return history, thought, "", "", err
+		}
+		response = strings.Trim(response, "\n")
+		history = append(history, Message{Role: RoleAssistant, Content: response})
+		thought = r.protocol.extractThought(response)
+		if r.protocol.isAnswer(response) {
+			return history, thought, "", response, nil
+		}
 	}
-	return fmt.Sprintf("%s\nTHOUGHT: %s", history, thought), result[len(result)-1], nil
+	r.logger.InfoContext(qr.ctx, "action", "step", qr.steps, "action", action)
+	return history, thought, action, response, nil
 }
 
-func compressPromptContext(prompt string) string {
-	// remove all lines starting with OBSERVATION:
-	lines := strings.Split(prompt, "\n")
-	var newlines []string
-	for _, line := range lines {
-		if !strings.HasPrefix(line, "OBSERVATION: ") {
-			newlines = append(newlines, line)
+// compressPromptContext returns a copy of the messages where all
+// observations but the last one are removed.
+func (r *React) compressPromptContext(qr *run, messages []Message) []Message {
+	last := -1
+	for i, message := range messages {
+		if r.isObservation(message) {
+			last = i
+		}
+	}
+	var compressed []Message
+	for i, message := range messages {
+		if i != last && r.isObservation(message) {
+			continue
 		}
+		compressed = append(compressed, message)
 	}
-	prompt = strings.Join(newlines, "\n")
-	fmt.Printf(prompt)
-	fmt.Printf("[context size: around %d tokens]\n", len(prompt)/4)
-	return prompt
+	tokens := r.countMessageTokens(compressed)
+	r.logger.DebugContext(qr.ctx, "truncated context", "step", qr.steps,
+		"tokens", tokens, "messages", len(compressed))
+	r.emit(qr, Event{Type: EventContextTruncated, Tokens: tokens})
+	return compressed
+}
+
+// commandNames returns the sorted names of the commands so that the
+// prompt is the same for each run (required for replaying recorded
+// requests).
+func (r *React) commandNames() []string {
+	names := make([]string, 0, len(r.commands))
+	for name := range r.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 func (r *React) commandDescriptions() string {
@@ -188,61 +355,138 @@ func (r *React) commandDescriptions() string {
 	descriptions = append(descriptions, "")
 	descriptions = append(descriptions, "command | argument | description")
 	descriptions = append(descriptions, "--------------------------------")
-	for name, command := range r.commands {
+	for _, name := range r.commandNames() {
+		command := r.commands[name]
+		argument, description := command.Argument, command.Description
+		if command.typed() {
+			argument = command.signature()
+			if parameters := command.parameterDescriptions(); parameters != "" {
+				description += " (" + parameters + ")"
+			}
+		}
 		descriptions = append(descriptions, fmt.Sprintf("%s | %s | %s",
-			name, command.Argument, command.Description))
+			name, argument, description))
 	}
 	descriptions = append(descriptions, "--------------------------------")
 	descriptions = append(descriptions, "")
 	return strings.Join(descriptions, "\n")
 }
 
-func (r *React) executeAction(action string) (string, error) {
-	command, argument, err := parseAction2(action)
+// executeAction parses the action, runs its command and records it
+// in step.
+func (r *React) executeAction(qr *run, step *Step, action string) (string, error) {
+	parsed, err := r.actionParser().Parse(action)
 	if err != nil {
-		return "", err
+		r.logger.InfoContext(qr.ctx, "unable to parse action", "step", qr.steps,
+			"action", action, "error", err)
+		step.Err = err
+		step.Observation = fmt.Sprintf("The action could not be parsed: %v. Write the action in the format: %s %s",
+			err, r.parser.Format(Action{Command: "command", Argument: "argument"}), r.protocol.StopAction)
+		return step.Observation, nil
+	}
+	return r.execute(qr, step, parsed)
+}
+
+// execute runs the command of the action and records it in step.
+func (r *React) execute(qr *run, step *Step, action Action) (string, error) {
+	r.emit(qr, Event{Type: EventAction, Command: action.Command, Argument: action.Argument})
+	err := r.applyActionHooks(qr, &action)
+	command, argument := action.Command, action.Argument
+	step.Command = command
+	step.Argument = argument
+	if err != nil {
+		r.logger.InfoContext(qr.ctx, "action rejected", "step", qr.steps,
+			"command", command, "argument", argument, "error", err)
+		step.Err = err
+		step.Observation = fmt.Sprintf("The action was rejected: %v", err)
+		return step.Observation, nil
 	}
 	cmd, exists := r.commands[command]
 	if !exists {
-		return fmt.Sprintf("The command %s is not known. Please use one of the following commands:\n%s",
-			command, r.commandDescriptions()), nil
+		step.Observation = fmt.Sprintf("The command %s is not known. Please use one of the following commands:\n%s",
+			command, r.commandDescriptions())
+		return step.Observation, nil
 		//return "", fmt.Errorf("unknown command: %s", command)
 	}
-	fmt.Printf("EXECUTING COMMAND: %s %s\n", command, argument)
-	return cmd.Func(argument)
+	var args Arguments
+	if cmd.typed() {
+		// invalid arguments are sent back to the LLM to correct them
+		if args, err = cmd.arguments(argument); err != nil {
+			r.logger.InfoContext(qr.ctx, "invalid arguments", "step", qr.steps,
+				"command", command, "argument", argument, "error", err)
+			step.Err = err
+			step.Observation = fmt.Sprintf("The arguments of %s are invalid: %v", command, err)
+			return step.Observation, nil
+		}
+	}
+	r.logger.InfoContext(qr.ctx, "executing command", "step", qr.steps,
+		"command", command, "argument", argument)
+	r.emit(qr, Event{Type: EventCommandStart, Command: command, Argument: argument})
+	started := time.Now()
+	step.Observation, step.Err = cmd.call(qr.ctx, argument, args)
+	step.CommandDuration = time.Since(started)
+	r.emit(qr, Event{Type: EventCommandFinish, Command: command, Argument: argument,
+		Observation: step.Observation, Duration: step.CommandDuration, Err: step.Err})
+	return step.Observation, step.Err
+}
+
+// compress shortens the observation and records it in step.
+func (r *React) compress(qr *run, step *Step, question, observation string) (string, error) {
+	// The observation of the action might be too long to serve
+	// as input for the next step. Hence we compress it to the
+	// summary size. Doing that by letting the LLM summarize the
+	// observation based on relevant information with regards
+	// to the question.
+	started := time.Now()
+	compressed, err := r.createSummaryOfSummaries(qr, question, observation, r.limits.SummarySize)
+	step.SummaryDuration = time.Since(started)
+	if err != nil {
+		return "", fmt.Errorf("unable to compress observation: %w", err)
+	}
+	step.CompressedObservation = compressed
+	if compressed != observation {
+		r.emit(qr, Event{Type: EventObservationCompressed, Command: step.Command,
+			Argument: step.Argument, Observation: compressed, Duration: step.SummaryDuration})
+	}
+	return compressed, nil
 }
 
-func (r *React) createSummaryOfSummaries(question, observation string, maxLen int) (string, error) {
+// createSummaryOfSummaries compresses the observation until it has
+// at most maxTokens tokens.
+func (r *React) createSummaryOfSummaries(qr *run, question, observation string, maxTokens int) (string, error) {
 	var err error
-	if len(observation) <= maxLen {
+	if r.countTokens(observation) <= maxTokens {
 		return observation, nil
 	}
 	// get last line which contains THOUGHT
 	thought := ""
 	for _, line := range strings.Split(observation, "\n") {
-		if strings.Contains(line, "THOUGHT:") {
+		if strings.Contains(line, r.protocol.Thought) {
 			thought = line
 		}
 	}
 
 	for {
 
-		before := len(observation)
-		observation, err = r.compressObservation(question+" "+thought, observation, maxLen)
+		before := r.countTokens(observation)
+		observation, err = r.compressObservation(qr, question+" "+thought, observation, maxTokens)
 		if err != nil {
-			return "", fmt.Errorf("unable to compress observation: %v", err)
+			return "", fmt.Errorf("unable to compress observation: %w", err)
 		}
-		after := len(observation)
+		after := r.countTokens(observation)
 		if before <= after {
 			// it does not get shorter
-			observation, err = r.llm.Request("Summarize in 3 sentences according to the question.",
+			observation, err = r.summarize(qr, "Summarize in 3 sentences according to the question.",
 				"Question: "+question+"\n"+"Here is the text to summarize in 3 sentences:\n"+observation+"\n")
+			if err != nil {
+				return "", fmt.Errorf("unable to summarize observation: %w", err)
+			}
 			break
 		}
 
-		if len(observation) > maxLen {
-			fmt.Printf("Summary too long (%d). Creating a summary of the summary.\n",
-				len(observation))
+		if after > maxTokens {
+			r.logger.DebugContext(qr.ctx, "summary too long, creating a summary of the summary",
+				"step", qr.steps, "tokens", after)
 			continue
 		} else {
 			break
@@ -251,52 +495,27 @@ func (r *React) createSummaryOfSummaries(question, observation string, maxLen in
 	return observation, nil
 }
 
-func (r *React) compressObservation(question, observation string, maxLen int) (string, error) {
+func (r *React) compressObservation(qr *run, question, observation string, maxTokens int) (string, error) {
 	// compress observation
-	if len(observation) <= maxLen {
+	if r.countTokens(observation) <= maxTokens {
 		return observation, nil
 	}
 	// go through the observation with a sliding window and
 	// create a summary which is related to the question
-	// take first 2048 characters (roughly 512 tokens)
-	stepSize := 2048
-	overlap := 32
-
-	from := 0
-	to := stepSize
-
+	system, err := r.renderPrompt(PromptSummarize, "")
+	if err != nil {
+		return "", err
+	}
 	fullSummary := ""
-
-	for {
-		if to > len(observation) {
-			to = len(observation) - 1
-		}
-		part := observation[from:to]
-
-		var summary string
-		var err error
-
-		for {
-			summary, err = r.llm.Request(PromptSummarize,
-				"Question: "+question+"\n"+"Here is the text to summarize in two sentences:\n"+part+"\n")
-			if err != nil {
-				if strings.Contains(err.Error(), "currently overloaded") {
-					// retry since API is overloaded...
-					<-time.Tick(5 * time.Second)
-					continue
-				}
-				return "", fmt.Errorf("failed to summarize observation: %v", err)
-			}
-			break
+	for _, part := range chunks(r.tokenizer, observation, r.limits.ChunkSize, r.limits.ChunkOverlap) {
+		summary, err := r.summarize(qr, system,
+			"Question: "+question+"\n"+"Here is the text to summarize in two sentences:\n"+part+"\n")
+		if err != nil {
+			return "", fmt.Errorf("failed to summarize observation: %w", err)
 		}
 
-		summary = strings.ReplaceAll(summary, "EMPTY", "")
+		summary = strings.ReplaceAll(summary, r.protocol.Empty, "")
 		fullSummary += summary
-		from += stepSize - overlap
-		if from > len(observation)-overlap {
-			break
-		}
-		to += stepSize - overlap
 	}
 
 	fullSummary = strings.Trim(fullSummary, "\n")
@@ -305,39 +524,3 @@ func (r *React) compressObservation(question, observation string, maxLen int) (s
 	}
 	return fullSummary, nil
 }
-
-func parseAction2(action string) (string, string, error) {
-	result := strings.Split(strings.TrimSuffix(action, "\n"), "\n")
-	resultAndArgs := strings.Split(result[0], " ")
-	if len(resultAndArgs) < 2 {
-		return resultAndArgs[0], "", nil
-	}
-	return resultAndArgs[0], strings.Trim(strings.Join(resultAndArgs[1:], " "), " \""), nil
-}
-
-func parseAction(action string) (string, string, error) {
-	// action contains a command and an argument
-	// { "command": "answer", "argument": "42" }
-	// answer: arguments for answer
-	result := strings.Split(strings.TrimSuffix(action, "\n"), "}")
-
-	commandAndArgument := strings.Split(strings.Trim(result[0], " "), ", ")
-	if len(commandAndArgument) < 2 {
-		return "", "", fmt.Errorf("unable to parse action: %s", action)
-	}
-
-	command := strings.Split(commandAndArgument[0], ": ")
-	if len(command) < 2 {
-		return "", "", fmt.Errorf("unable to parse action command: %s", action)
-	}
-	cmd := strings.Trim(command[1], "\" ")
-
-	argument := strings.Split(strings.Join(commandAndArgument[1:], ", "), ": ")
-	if len(argument) < 2 {
-		return "", "", fmt.Errorf("unable to parse action argument: %s", action)
-
-	}
-	arg := strings.Trim(strings.Join(argument[1:], ":"), "\" ")
-
-	return cmd, arg, nil
-}
@@ -0,0 +1,81 @@
+package goreact
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// scriptedProvider responds with the responses in sequence.
+type scriptedProvider struct {
+	responses []string
+}
+
+func (s *scriptedProvider) Request(ctx context.Context, system, prompt string) (string, error) {
+	if len(s.responses) == 0 {
+		return "", fmt.Errorf("no response left")
+	}
+	response := s.responses[0]
+	s.responses = s.responses[1:]
+	return response, nil
+}
+
+func TestCommandWithParameters(t *testing.T) {
+	parameters := []Parameter{
+		{Name: "query", Type: TypeString, Required: true},
+		{Name: "limit", Type: TypeInteger},
+	}
+	var received []string
+	tests := []struct {
+		name    string
+		command Command
+	}{
+		{"Func", Command{Parameters: parameters, Func: func(argument string) (string, error) {
+			received = append(received, argument)
+			return "found", nil
+		}}},
+		{"ContextFunc", Command{Parameters: parameters, ContextFunc: func(ctx context.Context, argument string) (string, error) {
+			received = append(received, argument)
+			return "found", nil
+		}}},
+		{"TypedFunc", Command{Parameters: parameters, TypedFunc: func(ctx context.Context, args Arguments) (string, error) {
+			received = append(received, fmt.Sprintf("%s %d", args.String("query"), args.Int("limit")))
+			return "found", nil
+		}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			received = nil
+			llm := &scriptedProvider{responses: []string{
+				"THOUGHT: I search.\nACTION: search {\"query\": \"goreact\", \"limit\": \"many\"}",
+				"THOUGHT: I fix the limit.\nACTION: search {\"query\": \"goreact\", \"limit\": 3}",
+				"ANSWER: found it",
+			}}
+			r, err := NewReact(llm, map[string]Command{"search": test.command})
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := r.QuestionResult(context.Background(), "Where is goreact?")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Answer != "found it" || len(result.Steps) != 3 {
+				t.Fatalf("unexpected result %+v", result)
+			}
+			// invalid arguments are sent back without calling the command
+			if result.Steps[0].Err == nil || result.Steps[1].Err != nil {
+				t.Errorf("unexpected errors %v, %v", result.Steps[0].Err, result.Steps[1].Err)
+			}
+			want := `{"query": "goreact", "limit": 3}`
+			if test.command.TypedFunc != nil {
+				want = "goreact 3"
+			}
+			if len(received) != 1 || received[0] != want {
+				t.Errorf("expected the command to be called with %q, got %q", want, received)
+			}
+			if result.Steps[1].Observation != "found" {
+				t.Errorf("unexpected observation %q", result.Steps[1].Observation)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package goreact
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoAction is returned when the LLM responds neither with an ACTION
+// nor with an ANSWER, also after all repair attempts.
+var ErrNoAction = errors.New("no action found")
+
+// DefaultRepairAttempts is the amount of times the LLM is asked to fix
+// a response without ACTION unless configured otherwise with
+// WithRepairAttempts.
+const DefaultRepairAttempts = 2
+
+// NoActionError wraps ErrNoAction and contains the last response of the
+// LLM which could not be parsed.
+type NoActionError struct {
+	Response string
+	Attempts int
+}
+
+func (e *NoActionError) Error() string {
+	return fmt.Sprintf("%v after %d repair attempts in response: %q", ErrNoAction, e.Attempts, e.Response)
+}
+
+func (e *NoActionError) Unwrap() error {
+	return ErrNoAction
+}
+
+// RepairFallback decides what happens with a response which could not
+// be repaired.
+type RepairFallback int
+
+const (
+	// FallbackError stops the loop with a *NoActionError.
+	FallbackError RepairFallback = iota
+	// FallbackAnswer uses the response as the answer.
+	FallbackAnswer
+)
+
+// WithRepairAttempts sets how often the LLM is asked to fix a response
+// which has neither an ACTION nor an ANSWER (default 2). The invalid
+// response is quoted in the request together with the required format.
+// Each attempt counts as a step.
+func (r *React) WithRepairAttempts(attempts int) *React {
+	r.repairAttempts = attempts
+	return r
+}
+
+// WithRepairFallback sets what happens when the response could not be
+// repaired (default FallbackError).
+func (r *React) WithRepairFallback(fallback RepairFallback) *React {
+	r.repairFallback = fallback
+	return r
+}
+
+// repairMessage asks the LLM to fix the invalid response.
+func (r *React) repairMessage(response string) (Message, error) {
+	prompt, err := r.renderPrompt(PromptRepair, response)
+	return Message{Role: RoleUser, Content: prompt}, err
+}
+
+// noAction applies the repair fallback on the response. It returns the
+// response in the answer format or a *NoActionError.
+func (r *React) noAction(qr *run, response string) (string, error) {
+	if r.repairFallback == FallbackAnswer {
+		r.logger.InfoContext(qr.ctx, "using response without action as answer", "step", qr.steps)
+		answer := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(response), r.protocol.Thought))
+		return r.protocol.Answer + " " + answer, nil
+	}
+	return "", &NoActionError{Response: response, Attempts: r.repairAttempts}
+}
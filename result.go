@@ -0,0 +1,71 @@
+package goreact
+
+import "time"
+
+// Result is the outcome of a question. Besides the final answer it
+// contains all steps the LLM went through to find the answer.
+type Result struct {
+	Question string
+	// Answer is the final answer without the ANSWER: prefix.
+	Answer string
+	// Steps contains the steps in the order they were executed. With
+	// the text protocol the last step has no command when the LLM
+	// concluded with an answer. With tool calling each step is a tool
+	// call or a rejected answer.
+	Steps []Step
+	// LLMCalls is the amount of all requests sent to the LLM
+	// including the summarization requests.
+	LLMCalls int
+	// SummarizationCalls is the amount of requests which were sent
+	// for compressing observations.
+	SummarizationCalls int
+	// VerificationCalls is the amount of requests which were sent
+	// for verifying answers.
+	VerificationCalls int
+	// PromptTokens and CompletionTokens are the tokens sent to and
+	// received from the LLM as reported by the providers. They are
+	// estimated for providers which do not report their usage.
+	PromptTokens     int
+	CompletionTokens int
+	// Usage contains the tokens and the cost per purpose, like
+	// reasoning or summarization.
+	Usage map[Purpose]Usage
+	// Cost is the estimated price of all requests based on the
+	// pricing configured with WithPricing.
+	Cost     float64
+	Duration time.Duration
+}
+
+// Step is one THOUGHT, ACTION, OBSERVATION cycle.
+type Step struct {
+	Thought  string
+	Command  string
+	Argument string
+	// Observation is the raw output of the command.
+	Observation string
+	// CompressedObservation is the observation which was sent back
+	// to the LLM. It is the summary of Observation when the
+	// observation was too long.
+	CompressedObservation string
+	// Err is the error returned by the command.
+	Err             error
+	ThoughtDuration time.Duration
+	CommandDuration time.Duration
+	SummaryDuration time.Duration
+}
+
+// TotalTokens returns the sum of prompt and completion tokens.
+func (r *Result) TotalTokens() int {
+	return r.PromptTokens + r.CompletionTokens
+}
+
+// addUsage accounts the usage of a request sent for purpose.
+func (r *Result) addUsage(purpose Purpose, usage Usage) {
+	if r.Usage == nil {
+		r.Usage = make(map[Purpose]Usage)
+	}
+	r.Usage[purpose] = r.Usage[purpose].Add(usage)
+	r.PromptTokens += usage.PromptTokens
+	r.CompletionTokens += usage.CompletionTokens
+	r.Cost += usage.Cost
+}
@@ -0,0 +1,156 @@
+package goreact
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryProvider wraps an LLMProvider and retries requests which failed
+// with a transient error (rate limit, overload, timeout) using
+// exponential backoff with jitter. A Retry-After delay requested by
+// the API is respected.
+type RetryProvider struct {
+	provider       LLMProvider
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	jitter         float64
+}
+
+// NewRetryProvider wraps provider with the default of 5 attempts and
+// a backoff of 1s which doubles up to 30s with 20% jitter.
+func NewRetryProvider(provider LLMProvider) *RetryProvider {
+	return &RetryProvider{
+		provider:       provider,
+		maxAttempts:    5,
+		initialBackoff: time.Second,
+		maxBackoff:     30 * time.Second,
+		jitter:         0.2,
+	}
+}
+
+// WithMaxAttempts sets the maximum amount of attempts including the
+// first request.
+func (r *RetryProvider) WithMaxAttempts(attempts int) *RetryProvider {
+	r.maxAttempts = attempts
+	return r
+}
+
+// WithBackoff sets the delay before the first retry and the maximum
+// delay. The delay doubles after each attempt.
+func (r *RetryProvider) WithBackoff(initial, max time.Duration) *RetryProvider {
+	r.initialBackoff = initial
+	r.maxBackoff = max
+	return r
+}
+
+// WithJitter sets the fraction (0 to 1) by which each delay is
+// randomly varied.
+func (r *RetryProvider) WithJitter(jitter float64) *RetryProvider {
+	r.jitter = jitter
+	return r
+}
+
+// Model returns the model of the wrapped provider.
+func (r *RetryProvider) Model() string {
+	if modeler, ok := r.provider.(Modeler); ok {
+		return modeler.Model()
+	}
+	return ""
+}
+
+func (r *RetryProvider) Request(ctx context.Context, system, prompt string) (string, error) {
+	var response string
+	err := r.retry(ctx, func() error {
+		var err error
+		response, err = r.provider.Request(ctx, system, prompt)
+		return err
+	})
+	return response, err
+}
+
+func (r *RetryProvider) Chat(ctx context.Context, messages []Message) (string, error) {
+	chat := asChatProvider(r.provider)
+	var response string
+	err := r.retry(ctx, func() error {
+		var err error
+		response, err = chat.Chat(ctx, messages)
+		return err
+	})
+	return response, err
+}
+
+// RequestTools retries the tool request when the wrapped provider
+// supports tools and returns ErrToolsNotSupported otherwise.
+func (r *RetryProvider) RequestTools(ctx context.Context, messages []Message, tools []Tool) (Message, error) {
+	toolProvider, ok := r.provider.(ToolProvider)
+	if !ok {
+		return Message{}, ErrToolsNotSupported
+	}
+	var response Message
+	err := r.retry(ctx, func() error {
+		var err error
+		response, err = toolProvider.RequestTools(ctx, messages, tools)
+		return err
+	})
+	return response, err
+}
+
+func (r *RetryProvider) retry(ctx context.Context, request func() error) error {
+	backoff := r.initialBackoff
+	for attempt := 1; ; attempt++ {
+		err := request()
+		if err == nil {
+			return nil
+		}
+		class := ClassifyError(err)
+		if !class.Transient() || ctx.Err() != nil {
+			return err
+		}
+		if attempt >= r.maxAttempts {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+		delay := r.delay(backoff)
+		if after := retryAfter(err); after > delay {
+			delay = after
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		backoff *= 2
+		if backoff > r.maxBackoff {
+			backoff = r.maxBackoff
+		}
+	}
+}
+
+// delay returns the backoff varied by the jitter.
+func (r *RetryProvider) delay(backoff time.Duration) time.Duration {
+	if r.jitter <= 0 {
+		return backoff
+	}
+	factor := 1 + r.jitter*(2*rand.Float64()-1)
+	return time.Duration(float64(backoff) * factor)
+}
+
+// WithRetry sets whether the requests to the LLM providers are retried
+// on transient errors (default true). The providers are wrapped by a
+// RetryProvider with its defaults. A provider which already is a
+// *RetryProvider is used as it is, so that its settings can be changed.
+func (r *React) WithRetry(enabled bool) *React {
+	r.retry = enabled
+	return r
+}
+
+// retrying returns provider wrapped by a RetryProvider when retries are
+// enabled.
+func (r *React) retrying(provider LLMProvider) LLMProvider {
+	if _, ok := provider.(*RetryProvider); ok || !r.retry {
+		return provider
+	}
+	return NewRetryProvider(provider)
+}
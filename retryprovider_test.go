@@ -0,0 +1,145 @@
+package goreact
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// flakyProvider fails with the errors before it succeeds.
+type flakyProvider struct {
+	errs     []error
+	requests int
+	times    []time.Time
+}
+
+func (f *flakyProvider) Request(ctx context.Context, system, prompt string) (string, error) {
+	f.requests++
+	f.times = append(f.times, time.Now())
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return "", err
+	}
+	return "ok", nil
+}
+
+func TestRetryProviderRetriesTransientErrors(t *testing.T) {
+	llm := &flakyProvider{errs: []error{
+		&ProviderError{StatusCode: 529},
+		&ProviderError{StatusCode: http.StatusTooManyRequests},
+	}}
+	retry := NewRetryProvider(llm).WithBackoff(10*time.Millisecond, 15*time.Millisecond).WithJitter(0)
+	response, err := retry.Request(context.Background(), "system", "prompt")
+	if err != nil || response != "ok" {
+		t.Fatalf("expected ok, got %q, %v", response, err)
+	}
+	if llm.requests != 3 {
+		t.Fatalf("expected 3 requests, got %d", llm.requests)
+	}
+	// the backoff doubles up to the maximum
+	if d := llm.times[1].Sub(llm.times[0]); d < 10*time.Millisecond {
+		t.Errorf("first retry after %v", d)
+	}
+	if d := llm.times[2].Sub(llm.times[1]); d < 15*time.Millisecond {
+		t.Errorf("second retry after %v", d)
+	}
+}
+
+func TestRetryProviderFatalError(t *testing.T) {
+	llm := &flakyProvider{errs: []error{&ProviderError{StatusCode: http.StatusUnauthorized}}}
+	_, err := NewRetryProvider(llm).WithBackoff(time.Millisecond, time.Millisecond).
+		Request(context.Background(), "system", "prompt")
+	if err == nil || llm.requests != 1 {
+		t.Fatalf("expected a single failed request, got %d requests and %v", llm.requests, err)
+	}
+}
+
+func TestRetryProviderGivesUp(t *testing.T) {
+	llm := &flakyProvider{errs: []error{ErrOverloaded, ErrOverloaded, ErrOverloaded}}
+	_, err := NewRetryProvider(llm).WithMaxAttempts(2).WithBackoff(time.Millisecond, time.Millisecond).
+		Request(context.Background(), "system", "prompt")
+	if !errors.Is(err, ErrOverloaded) || !strings.Contains(err.Error(), "giving up after 2 attempts") {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if llm.requests != 2 {
+		t.Errorf("expected 2 requests, got %d", llm.requests)
+	}
+}
+
+func TestRetryProviderRetryAfter(t *testing.T) {
+	llm := &flakyProvider{errs: []error{&ProviderError{StatusCode: 429, RetryAfter: 50 * time.Millisecond}}}
+	started := time.Now()
+	_, err := NewRetryProvider(llm).WithBackoff(time.Millisecond, time.Millisecond).
+		Request(context.Background(), "system", "prompt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(started); d < 50*time.Millisecond {
+		t.Errorf("the Retry-After delay was not respected, retried after %v", d)
+	}
+}
+
+func TestRetryProviderCancellation(t *testing.T) {
+	llm := &flakyProvider{errs: []error{ErrOverloaded, ErrOverloaded}}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	_, err := NewRetryProvider(llm).WithBackoff(time.Hour, time.Hour).
+		Request(ctx, "system", "prompt")
+	if !errors.Is(err, ErrOverloaded) {
+		t.Fatalf("expected the last error, got %v", err)
+	}
+	if d := time.Since(started); d > time.Second {
+		t.Errorf("the backoff was not cancelled, returned after %v", d)
+	}
+	if llm.requests != 1 {
+		t.Errorf("expected 1 request, got %d", llm.requests)
+	}
+}
+
+func TestReactRetries(t *testing.T) {
+	overloaded := func() *flakyProvider {
+		return &flakyProvider{errs: []error{&ProviderError{StatusCode: 529}}}
+	}
+	tests := []struct {
+		name     string
+		llm      *flakyProvider
+		provider func(llm *flakyProvider) LLMProvider
+		retry    bool
+		requests int
+	}{
+		{"configured retry provider", overloaded(), func(llm *flakyProvider) LLMProvider {
+			return NewRetryProvider(llm).WithBackoff(time.Millisecond, time.Millisecond)
+		}, true, 2},
+		{"without retries", overloaded(), func(llm *flakyProvider) LLMProvider { return llm }, false, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, err := NewReact(test.provider(test.llm), map[string]Command{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.WithRetry(test.retry)
+			_, err = r.chatProvider().Chat(context.Background(), []Message{{Role: RoleUser, Content: "?"}})
+			if (err == nil) != test.retry || test.llm.requests != test.requests {
+				t.Errorf("expected %d requests, got %d and %v", test.requests, test.llm.requests, err)
+			}
+		})
+	}
+
+	// providers are wrapped by default, also the ones of the roles
+	r, err := NewReact(overloaded(), map[string]Command{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.WithSummarizationProvider(overloaded())
+	for _, provider := range []any{r.retrying(r.llm), r.summarizationProvider()} {
+		if _, ok := provider.(*RetryProvider); !ok {
+			t.Errorf("expected a *RetryProvider, got %T", provider)
+		}
+	}
+}
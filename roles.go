@@ -0,0 +1,115 @@
+package goreact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Purpose tells for which role of the ReAct loop an LLM request is
+// sent. Each purpose can be served by a different provider, for
+// example a cheaper model for summarizing observations.
+type Purpose string
+
+const (
+	// PurposeReasoning requests produce thoughts, actions and answers.
+	PurposeReasoning Purpose = "reasoning"
+	// PurposeSummarization requests compress long observations.
+	PurposeSummarization Purpose = "summarization"
+	// PurposeVerification requests check the final answer.
+	PurposeVerification Purpose = "verification"
+)
+
+type purposeKey struct{}
+
+// ContextWithPurpose returns a context which carries the purpose of the
+// request to the provider.
+func ContextWithPurpose(ctx context.Context, purpose Purpose) context.Context {
+	return context.WithValue(ctx, purposeKey{}, purpose)
+}
+
+// PurposeFromContext returns the purpose of the request or an empty
+// purpose when the request was not sent by the ReAct loop.
+func PurposeFromContext(ctx context.Context) Purpose {
+	purpose, _ := ctx.Value(purposeKey{}).(Purpose)
+	return purpose
+}
+
+// WithSummarizationProvider sets the provider for compressing long
+// observations. By default the reasoning provider is used.
+func (r *React) WithSummarizationProvider(provider LLMProvider) *React {
+	r.summarizer = provider
+	return r
+}
+
+// WithVerificationProvider enables the verification of answers. Before
+// an answer is returned the provider checks whether it is supported by
+// the observations. A rejected answer is sent back to the reasoning
+// LLM as observation so that it can continue.
+func (r *React) WithVerificationProvider(provider LLMProvider) *React {
+	r.verifier = provider
+	return r
+}
+
+func (r *React) summarizationProvider() LLMProvider {
+	if r.summarizer != nil {
+		return r.retrying(r.summarizer)
+	}
+	return r.retrying(r.llm)
+}
+
+// verify asks the verification provider whether the answer is
+// supported by the history. It returns an empty reason when the answer
+// was accepted or no verification provider is configured.
+func (r *React) verify(qr *run, answer string, history []Message) (string, error) {
+	if r.verifier == nil {
+		return "", nil
+	}
+	var observations []string
+	for _, message := range history {
+		if message.Role == RoleTool {
+			observations = append(observations, r.protocol.Observation+" "+message.Content)
+		} else if r.isObservation(message) {
+			observations = append(observations, message.Content)
+		}
+	}
+	prompt := fmt.Sprintf("%s %s\n%s\n%s %s\n", r.protocol.Question, qr.result.Question,
+		strings.Join(observations, "\n"), r.protocol.Answer, answer)
+
+	system, err := r.renderPrompt(PromptVerify, "")
+	if err != nil {
+		return "", err
+	}
+	verifier := r.retrying(r.verifier)
+	response, err := r.request(qr, llmRequest{
+		purpose:  PurposeVerification,
+		provider: verifier,
+		system:   system,
+		prompt:   prompt,
+		tokens:   r.countTokens(system) + r.countTokens(prompt),
+	}, func(ctx context.Context) (string, error) {
+		return verifier.Request(ctx, system, prompt)
+	})
+	if err != nil {
+		var limitErr *LimitError
+		if errors.As(err, &limitErr) {
+			return "", err
+		}
+		return "", fmt.Errorf("unable to verify answer: %w", err)
+	}
+
+	reason, rejected := r.protocol.rejection(response)
+	if !rejected {
+		return "", nil
+	}
+	if reason == "" {
+		reason = "the answer is not supported by the observations"
+	}
+	r.logger.InfoContext(qr.ctx, "answer rejected", "step", qr.steps, "reason", reason)
+	return reason, nil
+}
+
+func rejectedAnswer(reason string) string {
+	return fmt.Sprintf("The answer was rejected: %s. Continue to find the answer.", reason)
+}
@@ -0,0 +1,158 @@
+package goreact
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// PromptData is passed to the template of the main prompt.
+type PromptData struct {
+	// Commands are sorted by their name.
+	Commands []CommandInfo
+	// CommandTable is the table of all commands with their arguments
+	// and descriptions.
+	CommandTable string
+	// Now is the current time of the clock set with WithClock and
+	// Date is its date like 2006-01-02.
+	Now  time.Time
+	Date string
+	// Vars are the variables set with WithPromptVars.
+	Vars     map[string]any
+	Protocol Protocol
+	parser   ActionParser
+}
+
+// CommandInfo describes a command for the main prompt.
+type CommandInfo struct {
+	Name string
+	// Argument is the description of the argument or the JSON
+	// signature of typed commands.
+	Argument string
+	// Description contains the descriptions of the parameters of
+	// typed commands.
+	Description string
+	Parameters  []Parameter
+	// Examples are the examples of the command written as actions in
+	// the format of the action parser.
+	Examples []string
+}
+
+// Action writes an action in the format of the action parser, like
+// {{.Action "calculate" "7*77"}} in a template.
+func (d PromptData) Action(command, argument string) string {
+	return d.parser.Format(Action{Command: command, Argument: argument})
+}
+
+// WithMainPrompt sets the system prompt which is used on every turn. It
+// is rendered with fmt.Sprintf where %s is replaced by the table of
+// commands; the prompt is no template, see WithPromptTemplate.
+func (r *React) WithMainPrompt(prompt string) *React {
+	r.mainPrompt = prompt
+	r.promptTemplate, r.promptErr = nil, nil
+	return r
+}
+
+// WithPromptTemplate sets the template of the system prompt which is
+// used on every turn, like the default BasicReActPrompt. The template is
+// a text/template which gets PromptData. An invalid template is
+// reported by the next question.
+func (r *React) WithPromptTemplate(prompt string) *React {
+	r.mainPrompt = prompt
+	r.promptTemplate, r.promptErr = parsePrompt(prompt)
+	return r
+}
+
+// WithPromptVars sets variables which are available as .Vars in the
+// template of the main prompt.
+func (r *React) WithPromptVars(vars map[string]any) *React {
+	r.promptVars = vars
+	return r
+}
+
+// WithClock sets the clock for the current date in the main prompt
+// (default time.Now). A fixed clock keeps the prompt stable, e.g. for
+// replaying recorded requests.
+func (r *React) WithClock(now func() time.Time) *React {
+	r.clock = now
+	return r
+}
+
+func parsePrompt(prompt string) (*template.Template, error) {
+	tmpl, err := template.New("main").Option("missingkey=zero").Parse(prompt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid main prompt: %w", err)
+	}
+	return tmpl, nil
+}
+
+// systemPrompt renders the main prompt.
+func (r *React) systemPrompt() (string, error) {
+	if r.promptErr != nil {
+		return "", r.promptErr
+	}
+	if r.promptTemplate == nil {
+		if strings.Contains(r.mainPrompt, "%s") {
+			return fmt.Sprintf(r.mainPrompt, r.commandDescriptions()), nil
+		}
+		return r.mainPrompt, nil
+	}
+	now := r.clock()
+	data := PromptData{
+		CommandTable: r.commandDescriptions(),
+		Now:          now,
+		Date:         now.Format(time.DateOnly),
+		Vars:         r.promptVars,
+		Protocol:     r.protocol,
+		parser:       r.parser,
+	}
+	for _, name := range r.commandNames() {
+		command := r.commands[name]
+		info := CommandInfo{
+			Name:        name,
+			Argument:    command.Argument,
+			Description: command.Description,
+			Parameters:  command.Parameters,
+		}
+		if command.typed() {
+			info.Argument = command.signature()
+			if parameters := command.parameterDescriptions(); parameters != "" {
+				info.Description += " (" + parameters + ")"
+			}
+		}
+		for _, example := range command.Examples {
+			info.Examples = append(info.Examples, data.Action(name, example))
+		}
+		data.Commands = append(data.Commands, info)
+	}
+	var prompt strings.Builder
+	if err := r.promptTemplate.Execute(&prompt, data); err != nil {
+		return "", fmt.Errorf("unable to render main prompt: %w", err)
+	}
+	return prompt.String(), nil
+}
+
+// renderPrompt renders one of the other prompts like PromptRepair with
+// the protocol and the response.
+func (r *React) renderPrompt(prompt, response string) (string, error) {
+	if !strings.Contains(prompt, "{{") {
+		if strings.Contains(prompt, "%s") {
+			return fmt.Sprintf(prompt, response), nil
+		}
+		return prompt, nil
+	}
+	tmpl, err := template.New("prompt").Parse(prompt)
+	if err != nil {
+		return "", fmt.Errorf("invalid prompt: %w", err)
+	}
+	var rendered strings.Builder
+	data := struct {
+		Protocol Protocol
+		Response string
+	}{r.protocol, response}
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return "", fmt.Errorf("unable to render prompt: %w", err)
+	}
+	return rendered.String(), nil
+}
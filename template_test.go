@@ -0,0 +1,116 @@
+package goreact
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// systemProvider records the system prompts of the scripted responses.
+type systemProvider struct {
+	scriptedProvider
+	systems []string
+}
+
+func (s *systemProvider) Request(ctx context.Context, system, prompt string) (string, error) {
+	s.systems = append(s.systems, system)
+	return s.scriptedProvider.Request(ctx, system, prompt)
+}
+
+func promptCommands() map[string]Command {
+	return map[string]Command{
+		"search": {Argument: "query", Description: "searches", Examples: []string{"goreact"},
+			Func: func(argument string) (string, error) { return "found", nil }},
+		"calculate": {Argument: "expression", Description: "calculates",
+			Func: func(argument string) (string, error) { return "539", nil }},
+	}
+}
+
+func TestPromptTemplate(t *testing.T) {
+	llm := &systemProvider{}
+	llm.responses = []string{"THOUGHT: I search.\nACTION: search goreact", "ANSWER: found"}
+	r, err := NewReact(llm, promptCommands())
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	r.WithPromptTemplate(`Team {{.Vars.team}} on {{.Date}}
+{{range .Commands}}{{.Name}}: {{.Argument}}{{range .Examples}} like {{.}}{{end}}
+{{end}}`).
+		WithPromptVars(map[string]any{"team": "platform"}).
+		WithClock(func() time.Time { return date })
+
+	if _, err := r.QuestionResult(context.Background(), "Where is goreact?"); err != nil {
+		t.Fatal(err)
+	}
+	want := "Team platform on 2024-05-01\ncalculate: expression\nsearch: query like search goreact\n"
+	if len(llm.systems) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(llm.systems))
+	}
+	// the commands are sorted and the same prompt is used on every turn
+	for i, system := range llm.systems {
+		if system != want {
+			t.Errorf("request %d: expected the system prompt\n%q, got\n%q", i, want, system)
+		}
+	}
+}
+
+func TestPromptTemplateDefault(t *testing.T) {
+	r, err := NewReact(&scriptedProvider{}, promptCommands())
+	if err != nil {
+		t.Fatal(err)
+	}
+	system, err := r.systemPrompt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"calculate | expression | calculates\nsearch | query | searches",
+		"ACTION: search goreact STOP_ACTION",
+	} {
+		if !strings.Contains(system, expected) {
+			t.Errorf("expected %q in the default prompt:\n%s", expected, system)
+		}
+	}
+	if strings.Contains(system, "{{") {
+		t.Errorf("expected a rendered prompt, got\n%s", system)
+	}
+}
+
+func TestPromptTemplateInvalid(t *testing.T) {
+	r, err := NewReact(&scriptedProvider{responses: []string{"ANSWER: 42"}}, promptCommands())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.WithPromptTemplate("{{range .Commands}}")
+	if _, err := r.Question("What is the answer?"); err == nil || !strings.Contains(err.Error(), "invalid main prompt") {
+		t.Errorf("expected an invalid template, got %v", err)
+	}
+	// a valid prompt replaces the invalid one
+	r.WithMainPrompt("Commands: %s")
+	if _, err := r.Question("What is the answer?"); err != nil {
+		t.Errorf("expected the answer, got %v", err)
+	}
+}
+
+func TestMainPromptNoTemplate(t *testing.T) {
+	r, err := NewReact(&scriptedProvider{}, promptCommands())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		prompt string
+		system string
+	}{
+		// braces of a prompt for fmt.Sprintf are no template
+		{"Write {{ not a template }} with the commands:%s", "Write {{ not a template }} with the commands:\ncommand | argument"},
+		{"Answer {{.Date}} without commands.", "Answer {{.Date}} without commands."},
+	}
+	for _, test := range tests {
+		system, err := r.WithMainPrompt(test.prompt).systemPrompt()
+		if err != nil || !strings.HasPrefix(system, test.system) {
+			t.Errorf("%q: expected the prompt %q, got %q, %v", test.prompt, test.system, system, err)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+//go:build ignore
+
+// download fetches the vocabularies published by OpenAI into the vocab
+// directory and verifies their checksums like tiktoken does.
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+var vocabularies = []struct {
+	name   string
+	sha256 string
+}{
+	{"cl100k_base", "223921b76ee99bde995b7ff738513eef100fb51d18c93597a113bcffe865b2a7"},
+	{"o200k_base", "446a9538cb6c348e3516120d7c08b09f57c36495e2acfffe59a5bf8b0cfb1a2d"},
+}
+
+func main() {
+	for _, vocabulary := range vocabularies {
+		if err := download(vocabulary.name, vocabulary.sha256); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to download %s: %v\n", vocabulary.name, err)
+			os.Exit(1)
+		}
+	}
+}
+
+func download(name, sum string) error {
+	resp, err := http.Get("https://openaipublic.blob.core.windows.net/encodings/" + name + ".tiktoken")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256(data)
+	if hex.EncodeToString(hash[:]) != sum {
+		return fmt.Errorf("unexpected checksum %x", hash)
+	}
+	return os.WriteFile(filepath.Join("vocab", name+".tiktoken"), data, 0644)
+}
@@ -0,0 +1,46 @@
+// Package tiktoken embeds the cl100k_base and o200k_base vocabularies
+// of OpenAI and registers them with goreact. Programs which import it
+// for its side effect measure the context of OpenAI models with their
+// exact tokens:
+//
+//	import _ "github.com/dgruber/goreact/tiktoken"
+//
+// The vocabularies add about 5 MB to the binary. They are downloaded
+// into the vocab directory with go generate. Encodings without a file
+// in the vocab directory are not registered, hence the tokens of their
+// models are approximated.
+package tiktoken
+
+//go:generate go run download.go
+
+import (
+	"embed"
+	"io"
+	"io/fs"
+
+	"github.com/dgruber/goreact"
+)
+
+//go:embed vocab
+var vocab embed.FS
+
+// Encodings are the encodings whose vocabularies are embedded.
+var Encodings []goreact.Encoding
+
+func init() {
+	for _, encoding := range []goreact.Encoding{goreact.EncodingCl100k, goreact.EncodingO200k} {
+		name := path(encoding)
+		if _, err := fs.Stat(vocab, name); err != nil {
+			continue
+		}
+		goreact.RegisterBPEVocabulary(encoding, func() (io.Reader, error) {
+			return vocab.Open(name)
+		})
+		Encodings = append(Encodings, encoding)
+	}
+}
+
+// path returns the path of the vocabulary in the embedded files.
+func path(encoding goreact.Encoding) string {
+	return "vocab/" + string(encoding) + ".tiktoken"
+}
@@ -0,0 +1,41 @@
+package tiktoken
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/dgruber/goreact"
+)
+
+// TestEncode pins the token ids of tiktoken for the embedded
+// vocabularies.
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		model string
+		text  string
+		ids   []int
+	}{
+		{"gpt-4", "hello world", []int{15339, 1917}},
+		{"gpt-4", "tiktoken is great!", []int{83, 1609, 5963, 374, 2294, 0}},
+		{"gpt-4", "hello world!你好，世界！", []int{15339, 1917, 0, 57668, 53901, 3922, 3574, 244, 98220, 6447}},
+		{"gpt-4o", "hello world", []int{24912, 2375}},
+		{"gpt-4o", "tiktoken is great!", []int{83, 8251, 2488, 382, 2212, 0}},
+	}
+	for _, test := range tests {
+		encoding, _ := goreact.EncodingForModel(test.model)
+		if !slices.Contains(Encodings, encoding) {
+			t.Logf("%s is not embedded, run go generate", encoding)
+			continue
+		}
+		tokenizer, err := goreact.BPETokenizerForModel(test.model)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids := tokenizer.Encode(test.text); !slices.Equal(ids, test.ids) {
+			t.Errorf("%s: Encode(%q) = %v, expected %v", test.model, test.text, ids, test.ids)
+		}
+		if text := tokenizer.Decode(test.ids); text != test.text {
+			t.Errorf("%s: Decode(%v) = %q", test.model, test.ids, text)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package goreact
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// Tokenizer splits text into the tokens of a language model. It is
+// used for measuring the size of the context and for splitting long
+// observations into chunks.
+type Tokenizer interface {
+	// Tokens returns the tokens of the text. Joining the tokens
+	// results in the text again.
+	Tokens(text string) []string
+}
+
+// ApproxTokenizer approximates the tokens of a text by splitting it
+// into pieces of 4 bytes. It is the default tokenizer as it needs no
+// vocabulary.
+type ApproxTokenizer struct{}
+
+func (ApproxTokenizer) Tokens(text string) []string {
+	var tokens []string
+	for len(text) > 0 {
+		end := 0
+		for end < len(text) && end < 4 {
+			_, size := utf8.DecodeRuneInString(text[end:])
+			end += size
+		}
+		tokens = append(tokens, text[:end])
+		text = text[end:]
+	}
+	return tokens
+}
+
+// ContextLimits defines the sizes in tokens which are used for keeping
+// the context of the LLM small.
+type ContextLimits struct {
+	// ContextWindow is the amount of tokens of the conversation after
+	// which all but the last observation are removed from it.
+	ContextWindow int
+	// ChunkSize is the amount of tokens of a part of a long
+	// observation which is summarized with one request.
+	ChunkSize int
+	// ChunkOverlap is the amount of tokens the parts overlap. Use
+	// NoOverlap for chunks without overlap in WithContextLimits.
+	ChunkOverlap int
+	// SummarySize is the amount of tokens an observation is
+	// compressed to when it is longer.
+	SummarySize int
+}
+
+// DefaultContextLimits are the limits used for models without known
+// limits unless configured otherwise with WithContextLimits.
+var DefaultContextLimits = ContextLimits{
+	ContextWindow: 14000,
+	ChunkSize:     512,
+	ChunkOverlap:  8,
+	SummarySize:   128,
+}
+
+// NoOverlap is the ChunkOverlap of chunks which do not overlap. 0 keeps
+// the default overlap in WithContextLimits.
+const NoOverlap = -1
+
+// contextWindows are the context windows in tokens of common models by
+// the prefix of their name. Longer prefixes come first.
+var contextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"gpt-4.1", 1047576},
+	{"gpt-4o", 128000},
+	{"gpt-4.5", 128000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4-32k", 32768},
+	{"gpt-4", 8192},
+	{"gpt-5", 400000},
+	{"gpt-3.5-turbo", 16385},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4", 200000},
+	{"claude-", 200000},
+	{"llama3.1", 131072},
+	{"llama3.2", 131072},
+	{"llama3.3", 131072},
+	{"llama3", 8192},
+	{"mistral", 32768},
+	{"qwen2.5", 32768},
+}
+
+// ContextLimitsForModel returns the limits for a model. The context
+// window leaves an eighth of the window of the model for the response.
+// For unknown models DefaultContextLimits and false are returned.
+func ContextLimitsForModel(model string) (ContextLimits, bool) {
+	limits := DefaultContextLimits
+	for _, window := range contextWindows {
+		if strings.HasPrefix(model, window.prefix) {
+			limits.ContextWindow = window.tokens - window.tokens/8
+			return limits, true
+		}
+	}
+	return limits, false
+}
+
+// contextLimits returns the limits for the model of the provider when
+// it implements Modeler.
+func contextLimits(provider LLMProvider) ContextLimits {
+	if modeler, ok := provider.(Modeler); ok {
+		limits, _ := ContextLimitsForModel(modeler.Model())
+		return limits
+	}
+	return DefaultContextLimits
+}
+
+// tokenizerFor returns the BPE tokenizer of the model of the provider when
+// its vocabulary was registered, see RegisterBPEVocabulary, and
+// ApproxTokenizer otherwise.
+func tokenizerFor(provider LLMProvider) (Tokenizer, error) {
+	modeler, ok := provider.(Modeler)
+	if !ok {
+		return ApproxTokenizer{}, nil
+	}
+	tokenizer, err := BPETokenizerForModel(modeler.Model())
+	if errors.Is(err, ErrNoVocabulary) {
+		return ApproxTokenizer{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tokenizer, nil
+}
+
+// WithTokenizer sets the tokenizer of the model which is used for
+// measuring the context and observations. By default the BPE tokenizer
+// of the model is used when the provider implements Modeler and the
+// vocabulary of the model was registered, otherwise the tokens are
+// approximated with ApproxTokenizer.
+func (r *React) WithTokenizer(tokenizer Tokenizer) *React {
+	r.tokenizer = tokenizer
+	return r
+}
+
+// WithContextLimits sets the sizes in tokens of the context window,
+// of the chunks of long observations, and of their summaries. They
+// should fit the model and are measured with the configured
+// tokenizer. Sizes which are 0 keep their defaults, which are taken
+// from ContextLimitsForModel when the provider implements Modeler. A
+// ChunkOverlap of NoOverlap turns the overlap off.
+func (r *React) WithContextLimits(limits ContextLimits) *React {
+	if limits.ContextWindow > 0 {
+		r.limits.ContextWindow = limits.ContextWindow
+	}
+	if limits.ChunkSize > 0 {
+		r.limits.ChunkSize = limits.ChunkSize
+	}
+	if limits.ChunkOverlap != 0 {
+		r.limits.ChunkOverlap = max(limits.ChunkOverlap, 0)
+	}
+	if limits.SummarySize > 0 {
+		r.limits.SummarySize = limits.SummarySize
+	}
+	return r
+}
+
+// countTokens returns the amount of tokens of the text.
+func (r *React) countTokens(text string) int {
+	return len(r.tokenizer.Tokens(text))
+}
+
+func (r *React) countMessageTokens(messages []Message) int {
+	tokens := 0
+	for _, message := range messages {
+		tokens += r.countTokens(message.Content)
+	}
+	return tokens
+}
+
+// chunks splits the text into parts of size tokens which overlap by
+// overlap tokens.
+func chunks(tokenizer Tokenizer, text string, size, overlap int) []string {
+	tokens := tokenizer.Tokens(text)
+	if overlap >= size {
+		overlap = 0
+	}
+	var parts []string
+	for from := 0; from < len(tokens); from += size - overlap {
+		to := min(from+size, len(tokens))
+		parts = append(parts, strings.Join(tokens[from:to], ""))
+		if to == len(tokens) {
+			break
+		}
+	}
+	return parts
+}
@@ -0,0 +1,77 @@
+package goreact
+
+import "testing"
+
+func TestContextLimitsForModel(t *testing.T) {
+	tests := []struct {
+		model         string
+		contextWindow int
+		known         bool
+	}{
+		{"gpt-4o-mini", 112000, true},
+		{"gpt-4-turbo", 112000, true},
+		{"gpt-4", 7168, true},
+		{"gpt-3.5-turbo", 14337, true},
+		{"claude-3-5-sonnet-20241022", 175000, true},
+		{"llama3:8b", 7168, true},
+		{"llama3.1:70b", 114688, true},
+		{"phi3", DefaultContextLimits.ContextWindow, false},
+	}
+	for _, test := range tests {
+		limits, known := ContextLimitsForModel(test.model)
+		if known != test.known || limits.ContextWindow != test.contextWindow {
+			t.Errorf("%s: expected context window %d (%v), got %d (%v)",
+				test.model, test.contextWindow, test.known, limits.ContextWindow, known)
+		}
+		if limits.ChunkSize != DefaultContextLimits.ChunkSize {
+			t.Errorf("%s: unexpected chunk size %d", test.model, limits.ChunkSize)
+		}
+	}
+}
+
+func TestNewReactContextLimits(t *testing.T) {
+	anthropic, err := NewAnthropicProvider("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewReact(NewRetryProvider(anthropic), map[string]Command{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.limits.ContextWindow != 175000 {
+		t.Errorf("expected the context window of the model, got %d", r.limits.ContextWindow)
+	}
+	r.WithContextLimits(ContextLimits{ContextWindow: 1000})
+	if r.limits.ContextWindow != 1000 || r.limits.SummarySize != DefaultContextLimits.SummarySize {
+		t.Errorf("unexpected limits %+v", r.limits)
+	}
+
+	r, err = NewReact(&countingProvider{}, map[string]Command{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.limits != DefaultContextLimits {
+		t.Errorf("expected the default limits without model, got %+v", r.limits)
+	}
+}
+
+func TestWithContextLimitsChunkOverlap(t *testing.T) {
+	tests := []struct {
+		overlap int
+		want    int
+	}{
+		{overlap: 0, want: DefaultContextLimits.ChunkOverlap},
+		{overlap: 16, want: 16},
+		{overlap: NoOverlap, want: 0},
+	}
+	for _, test := range tests {
+		r, err := NewReact(&countingProvider{}, map[string]Command{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.WithContextLimits(ContextLimits{ChunkOverlap: test.overlap})
+		if r.limits.ChunkOverlap != test.want {
+			t.Errorf("ChunkOverlap %d: expected %d, got %d", test.overlap, test.want, r.limits.ChunkOverlap)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package goreact
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Roles of the messages in a chat conversation.
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
+// Message is a message of a chat conversation.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	// ToolCalls are the tools the assistant wants to call.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// ToolCallID links the result of a tool (RoleTool) to the
+	// tool call of the assistant.
+	ToolCallID string `json:"tool_call_id,omitempty"`
+}
+
+// ToolCall is a request of the LLM to call a tool. Arguments are
+// JSON encoded.
+type ToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// Tool describes a command which can be called by the LLM natively.
+// Parameters is the JSON schema of the arguments.
+type Tool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  map[string]any `json:"parameters"`
+}
+
+// ToolProvider is implemented by LLM providers which support native
+// tool calling. The response is the assistant message which either
+// contains tool calls or the final answer.
+type ToolProvider interface {
+	RequestTools(ctx context.Context, messages []Message, tools []Tool) (Message, error)
+}
+
+// ErrToolsNotSupported is returned by a ToolProvider when the model
+// does not support tool calling. React then falls back to the text
+// protocol.
+var ErrToolsNotSupported = errors.New("tool calling not supported")
+
+// toolArgument is the name of the single parameter of a command
+// when it is called as a tool.
+const toolArgument = "argument"
+
+// WithToolCalling lets React send the commands as native tools to the
+// LLM when the provider implements ToolProvider. The tool calls of
+// the model are executed directly, hence the actions do not need to
+// be parsed from text. For other providers the text protocol is used.
+func (r *React) WithToolCalling(enabled bool) *React {
+	r.toolCalling = enabled
+	return r
+}
+
+// tools returns the tool definitions of all commands.
+func (r *React) tools() []Tool {
+	var tools []Tool
+	for _, name := range r.commandNames() {
+		command := r.commands[name]
+		if command.typed() {
+			tools = append(tools, Tool{
+				Name:        name,
+				Description: command.Description,
+				Parameters:  command.schema(),
+			})
+			continue
+		}
+		tools = append(tools, Tool{
+			Name:        name,
+			Description: command.Description,
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					toolArgument: map[string]any{
+						"type":        "string",
+						"description": command.Argument,
+					},
+				},
+				"required": []string{toolArgument},
+			},
+		})
+	}
+	return tools
+}
+
+// toolLoop answers the question with native tool calls. Each executed
+// tool call counts as a step, a rejected answer as well. The accepted
+// answer is no step of its own. It returns ErrToolsNotSupported when
+// the provider rejected the first request so that the caller can fall
+// back to the text protocol. No step was taken then, the rejected
+// request stays in the LLM calls and events since it was sent.
+func (r *React) toolLoop(qr *run, provider ToolProvider, question string) error {
+	tools := r.tools()
+	system, err := r.renderPrompt(PromptToolCalling, "")
+	if err != nil {
+		return err
+	}
+	messages := []Message{
+		{Role: RoleSystem, Content: system},
+		{Role: RoleUser, Content: question},
+	}
+	for {
+		if err := r.checkSteps(qr); err != nil {
+			return r.stop(qr, err, r.protocol.toolTranscript(messages))
+		}
+		started := time.Now()
+		response, err := r.requestTools(qr, provider, messages, tools)
+		if err != nil {
+			if errors.Is(err, ErrToolsNotSupported) && len(messages) == 2 {
+				return err
+			}
+			return r.stop(qr, err, r.protocol.toolTranscript(messages))
+		}
+		thought := r.protocol.extractThought(response.Content)
+		duration := time.Since(started)
+		messages = append(messages, response)
+		if len(response.ToolCalls) == 0 {
+			answer := r.protocol.extractAnswer(response.Content)
+			reason, err := r.verify(qr, answer, messages)
+			if err != nil {
+				return r.stop(qr, err, r.protocol.toolTranscript(messages))
+			}
+			if reason == "" {
+				if thought != "" {
+					r.emit(qr, Event{Type: EventThought, Thought: thought, Duration: duration})
+				}
+				r.setAnswer(qr, answer)
+				return nil
+			}
+			if err := r.step(qr); err != nil {
+				return r.stop(qr, err, r.protocol.toolTranscript(messages))
+			}
+			r.setThought(qr, qr.newStep(), thought, duration)
+			messages = append(messages, Message{Role: RoleUser, Content: rejectedAnswer(reason)})
+			continue
+		}
+
+		for i, call := range response.ToolCalls {
+			if err := r.step(qr); err != nil {
+				return r.stop(qr, err, r.protocol.toolTranscript(messages))
+			}
+			step := qr.newStep()
+			if i == 0 {
+				r.setThought(qr, step, thought, duration)
+			}
+			argument, err := r.toolCallArgument(call)
+			var observation string
+			if err != nil {
+				step.Command = call.Name
+				step.Err = err
+				observation = err.Error()
+			} else {
+				observation, err = r.execute(qr, step, Action{Command: call.Name, Argument: argument})
+				if err != nil && (observation == "" || qr.ctx.Err() != nil) {
+					return r.stop(qr, r.checkError(qr, err), r.protocol.toolTranscript(messages))
+				}
+				observation, err = r.compress(qr, step, question, observation)
+				if err != nil {
+					return r.stop(qr, err, r.protocol.toolTranscript(messages))
+				}
+			}
+			messages = append(messages, Message{
+				Role:       RoleTool,
+				Content:    observation,
+				ToolCallID: call.ID,
+			})
+		}
+	}
+}
+
+func (r *React) requestTools(qr *run, provider ToolProvider, messages []Message, tools []Tool) (Message, error) {
+	var response Message
+	_, err := r.request(qr, r.reasoningRequest(messages), func(ctx context.Context) (string, error) {
+		var err error
+		response, err = provider.RequestTools(ctx, messages, tools)
+		return toolResponseText(response), err
+	})
+	if err != nil {
+		return Message{}, err
+	}
+	return response, nil
+}
+
+// toolResponseText returns the content of a response together with its
+// tool calls.
+func toolResponseText(response Message) string {
+	text := response.Content
+	for _, call := range response.ToolCalls {
+		text += "\n" + call.Name + " " + call.Arguments
+	}
+	return strings.TrimSpace(text)
+}
+
+// toolCallArgument returns the argument of a tool call. Plain strings
+// are accepted as well since some models do not stick to the schema.
+// Typed commands get all arguments as JSON object.
+func (r *React) toolCallArgument(call ToolCall) (string, error) {
+	if command, ok := r.commands[call.Name]; ok && command.typed() {
+		return call.Arguments, nil
+	}
+	if strings.TrimSpace(call.Arguments) == "" {
+		return "", nil
+	}
+	var arguments map[string]any
+	if err := json.Unmarshal([]byte(call.Arguments), &arguments); err != nil {
+		return call.Arguments, nil
+	}
+	argument, exists := arguments[toolArgument]
+	if !exists {
+		return "", fmt.Errorf("the argument %q is missing in the call of %s",
+			toolArgument, call.Name)
+	}
+	if s, ok := argument.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprintf("%v", argument), nil
+}
+
+// toolTranscript turns the tool conversation into a conversation of
+// the text protocol so that a final answer can be requested from any
+// ChatProvider when a limit was hit.
+func (p Protocol) toolTranscript(messages []Message) []Message {
+	if len(messages) <= 2 {
+		return messages
+	}
+	return []Message{
+		{Role: RoleSystem, Content: messages[0].Content},
+		{Role: RoleUser, Content: p.Question + " " + messages[1].Content},
+		{Role: RoleUser, Content: p.toolHistory(messages[2:])},
+	}
+}
+
+// toolHistory renders the messages in the format of the text protocol.
+func (p Protocol) toolHistory(messages []Message) string {
+	var history []string
+	for _, message := range messages {
+		switch message.Role {
+		case RoleUser:
+			history = append(history, p.Question+" "+message.Content)
+		case RoleAssistant:
+			if message.Content != "" {
+				history = append(history, p.Thought+" "+message.Content)
+			}
+			for _, call := range message.ToolCalls {
+				history = append(history, p.Action+" "+call.Name+" "+call.Arguments)
+			}
+		case RoleTool:
+			history = append(history, p.Observation+" "+message.Content)
+		}
+	}
+	return strings.Join(history, "\n")
+}
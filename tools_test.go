@@ -0,0 +1,149 @@
+package goreact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// toolProvider responds to tool requests with the scripted messages
+// and to text requests with the scripted responses.
+type toolProvider struct {
+	scriptedProvider
+	messages    []Message
+	unsupported bool
+	toolCalls   int
+}
+
+func (p *toolProvider) RequestTools(ctx context.Context, messages []Message, tools []Tool) (Message, error) {
+	p.toolCalls++
+	if p.unsupported {
+		return Message{}, fmt.Errorf("%w: no tools for this model", ErrToolsNotSupported)
+	}
+	if len(p.messages) == 0 {
+		return Message{}, fmt.Errorf("no message left")
+	}
+	message := p.messages[0]
+	p.messages = p.messages[1:]
+	return message, nil
+}
+
+func lookTwice() Message {
+	return Message{
+		Role:    RoleAssistant,
+		Content: "I look in both directions.",
+		ToolCalls: []ToolCall{
+			{ID: "1", Name: "look", Arguments: `{"argument": "north"}`},
+			{ID: "2", Name: "look", Arguments: `{"argument": "south"}`},
+		},
+	}
+}
+
+func TestToolLoopSteps(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxSteps int
+		answer   string
+		err      error
+		looks    []string
+	}{
+		{name: "each tool call is a step", maxSteps: 3, answer: "1 coin", looks: []string{"north", "south"}},
+		{name: "limit after a response", maxSteps: 2, err: ErrStepLimit, looks: []string{"north", "south"}},
+		{name: "limit within a response", maxSteps: 1, err: ErrStepLimit, looks: []string{"north"}},
+		{name: "no limit", answer: "1 coin", looks: []string{"north", "south"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var arguments []string
+			llm := &toolProvider{messages: []Message{
+				lookTwice(),
+				{Role: RoleAssistant, Content: "ANSWER: 1 coin"},
+			}}
+			r, err := NewReact(llm, lookCommands(&arguments))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.WithToolCalling(true).WithMaxSteps(test.maxSteps)
+
+			result, err := r.QuestionResult(context.Background(), "How many coins are there?")
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected error %v, got %v", test.err, err)
+			}
+			if result.Answer != test.answer {
+				t.Errorf("expected answer %q, got %q", test.answer, result.Answer)
+			}
+			if fmt.Sprint(arguments) != fmt.Sprint(test.looks) {
+				t.Errorf("expected looks %v, got %v", test.looks, arguments)
+			}
+			// only executed tool calls are steps, the answer is none
+			if len(result.Steps) != len(test.looks) {
+				t.Fatalf("expected %d steps, got %+v", len(test.looks), result.Steps)
+			}
+			for i, step := range result.Steps {
+				if step.Command != "look" || step.Argument != test.looks[i] {
+					t.Errorf("unexpected step %d: %+v", i, step)
+				}
+			}
+			if result.Steps[0].Thought != "I look in both directions." {
+				t.Errorf("expected the thought in the first step, got %q", result.Steps[0].Thought)
+			}
+		})
+	}
+}
+
+func TestToolLoopFallback(t *testing.T) {
+	var arguments []string
+	llm := &toolProvider{unsupported: true}
+	llm.responses = []string{
+		"THOUGHT: I need to look.\nACTION: look north",
+		"ANSWER: 1 coin",
+	}
+	r, err := NewReact(llm, lookCommands(&arguments))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var steps []int
+	r.WithToolCalling(true).WithMaxSteps(2).WithObserver(func(ctx context.Context, event Event) {
+		if event.Type == EventLLMRequest {
+			steps = append(steps, event.Step)
+		}
+	})
+
+	result, err := r.QuestionResult(context.Background(), "How many coins are there?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Answer != "1 coin" || len(result.Steps) != 2 || result.Steps[0].Argument != "north" {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if llm.toolCalls != 1 {
+		t.Errorf("expected 1 tool request, got %d", llm.toolCalls)
+	}
+	// the rejected tool request was sent before any step was taken
+	if result.LLMCalls != 3 || fmt.Sprint(steps) != "[0 1 2]" {
+		t.Errorf("unexpected %d LLM calls in steps %v", result.LLMCalls, steps)
+	}
+}
+
+func TestToolLoopRejectedAnswer(t *testing.T) {
+	llm := &toolProvider{messages: []Message{
+		{Role: RoleAssistant, Content: "ANSWER: 2 coins"},
+		{Role: RoleAssistant, Content: "ANSWER: 1 coin"},
+	}}
+	llm.responses = []string{"INVALID: nothing was observed", "VALID"}
+	r, err := NewReact(llm, lookCommands(new([]string)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.WithToolCalling(true).WithVerificationProvider(llm)
+
+	result, err := r.QuestionResult(context.Background(), "How many coins are there?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the rejected answer is a step, the accepted one is none
+	if result.Answer != "1 coin" || len(result.Steps) != 1 || result.VerificationCalls != 2 {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
@@ -0,0 +1,144 @@
+package goreact
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// Usage is the amount of tokens consumed by one or more LLM requests.
+type Usage struct {
+	// Model is the model which served the requests. It is empty when
+	// the requests were served by different models.
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	// Estimated is true when at least one provider did not report
+	// its token usage and the tokens were estimated from the length
+	// of the text.
+	Estimated bool
+	// Cost is the price of the tokens based on the pricing configured
+	// with WithPricing.
+	Cost float64
+}
+
+// TotalTokens returns the sum of prompt and completion tokens.
+func (u Usage) TotalTokens() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// Add returns the sum of both usages.
+func (u Usage) Add(other Usage) Usage {
+	if u.Model != other.Model && u.TotalTokens() > 0 {
+		other.Model = ""
+	}
+	return Usage{
+		Model:            other.Model,
+		PromptTokens:     u.PromptTokens + other.PromptTokens,
+		CompletionTokens: u.CompletionTokens + other.CompletionTokens,
+		Estimated:        u.Estimated || other.Estimated,
+		Cost:             u.Cost + other.Cost,
+	}
+}
+
+// Price is the price of one million prompt and completion tokens in
+// an arbitrary currency.
+type Price struct {
+	Prompt     float64
+	Completion float64
+}
+
+// Pricing maps model names to prices. A model without an exact entry
+// uses the price of the longest model name which is a prefix of it,
+// so that "gpt-4o" also covers "gpt-4o-2024-08-06". The entry with
+// the empty name is used for all other models.
+type Pricing map[string]Price
+
+// Price returns the price of the model.
+func (p Pricing) Price(model string) (Price, bool) {
+	if price, ok := p[model]; ok {
+		return price, true
+	}
+	best := -1
+	var price Price
+	for name, candidate := range p {
+		if name != "" && len(name) > best && strings.HasPrefix(model, name) {
+			best, price = len(name), candidate
+		}
+	}
+	if best >= 0 {
+		return price, true
+	}
+	price, ok := p[""]
+	return price, ok
+}
+
+// Cost returns the price of the tokens of the usage.
+func (p Pricing) Cost(usage Usage) float64 {
+	price, ok := p.Price(usage.Model)
+	if !ok {
+		return 0
+	}
+	return (float64(usage.PromptTokens)*price.Prompt +
+		float64(usage.CompletionTokens)*price.Completion) / 1e6
+}
+
+// WithPricing sets the prices which are used to estimate the cost of
+// each LLM request. The cost is reported on the Result and in the
+// EventLLMResponse events.
+func (r *React) WithPricing(pricing Pricing) *React {
+	r.pricing = pricing
+	return r
+}
+
+type usageKey struct{}
+
+// usageCollector sums up the usage reported by the providers of a
+// single request. Decorating providers like RetryProvider and
+// FailoverProvider can cause multiple reports.
+type usageCollector struct {
+	mu       sync.Mutex
+	usage    Usage
+	reported bool
+}
+
+func withUsageCollector(ctx context.Context) (context.Context, *usageCollector) {
+	collector := &usageCollector{}
+	return context.WithValue(ctx, usageKey{}, collector), collector
+}
+
+// ReportUsage is called by providers with the token usage returned by
+// the LLM API. React uses it for the accounting of the request which
+// was sent with ctx. Providers which do not report their usage are
+// accounted with estimated tokens. Reporting a usage without tokens
+// marks a request as free, for example when it was served from a
+// cache.
+func ReportUsage(ctx context.Context, usage Usage) {
+	collector, ok := ctx.Value(usageKey{}).(*usageCollector)
+	if !ok {
+		return
+	}
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	if !collector.reported {
+		collector.usage = usage
+		collector.reported = true
+		return
+	}
+	collector.usage = collector.usage.Add(usage)
+}
+
+// collected returns the reported usage.
+func (c *usageCollector) collected() (Usage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.usage, c.reported
+}
+
+// modelOf returns the model of the provider when it is known.
+func modelOf(provider any) string {
+	if modeler, ok := provider.(Modeler); ok {
+		return modeler.Model()
+	}
+	return ""
+}
@@ -0,0 +1,143 @@
+package goreact
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestPricingPrice(t *testing.T) {
+	pricing := Pricing{
+		"gpt-4o":      {Prompt: 2.5, Completion: 10},
+		"gpt-4o-mini": {Prompt: 0.15, Completion: 0.6},
+		"claude":      {Prompt: 3, Completion: 15},
+	}
+	tests := []struct {
+		model string
+		price Price
+		found bool
+	}{
+		{model: "gpt-4o", price: pricing["gpt-4o"], found: true},
+		{model: "gpt-4o-2024-08-06", price: pricing["gpt-4o"], found: true},
+		// the longest prefix wins
+		{model: "gpt-4o-mini-2024-07-18", price: pricing["gpt-4o-mini"], found: true},
+		{model: "claude-3-5-sonnet-latest", price: pricing["claude"], found: true},
+		{model: "llama3", found: false},
+		{model: "", found: false},
+	}
+	for _, test := range tests {
+		price, found := pricing.Price(test.model)
+		if price != test.price || found != test.found {
+			t.Errorf("Price(%q) = %v, %v, expected %v, %v", test.model, price, found, test.price, test.found)
+		}
+	}
+
+	pricing[""] = Price{Prompt: 1, Completion: 1}
+	if price, found := pricing.Price("llama3"); !found || price != pricing[""] {
+		t.Errorf("expected the default price, got %v, %v", price, found)
+	}
+}
+
+func TestPricingCost(t *testing.T) {
+	pricing := Pricing{"gpt-4o": {Prompt: 2.5, Completion: 10}}
+	tests := []struct {
+		usage Usage
+		cost  float64
+	}{
+		{Usage{Model: "gpt-4o", PromptTokens: 1e6}, 2.5},
+		{Usage{Model: "gpt-4o", CompletionTokens: 1e6}, 10},
+		{Usage{Model: "gpt-4o-2024-08-06", PromptTokens: 1000, CompletionTokens: 100}, 0.0035},
+		{Usage{Model: "llama3", PromptTokens: 1000, CompletionTokens: 100}, 0},
+		{Usage{Model: "gpt-4o"}, 0},
+	}
+	for _, test := range tests {
+		if cost := pricing.Cost(test.usage); math.Abs(cost-test.cost) > 1e-12 {
+			t.Errorf("Cost(%+v) = %v, expected %v", test.usage, cost, test.cost)
+		}
+	}
+}
+
+func TestUsageAdd(t *testing.T) {
+	tests := []struct {
+		name   string
+		usages []Usage
+		sum    Usage
+	}{
+		{"same model", []Usage{
+			{Model: "gpt-4o", PromptTokens: 10, CompletionTokens: 1, Cost: 1},
+			{Model: "gpt-4o", PromptTokens: 20, CompletionTokens: 2, Cost: 2},
+		}, Usage{Model: "gpt-4o", PromptTokens: 30, CompletionTokens: 3, Cost: 3}},
+		{"different models", []Usage{
+			{Model: "gpt-4o", PromptTokens: 10},
+			{Model: "llama3", PromptTokens: 20},
+			{Model: "gpt-4o", PromptTokens: 30},
+		}, Usage{PromptTokens: 60}},
+		{"estimated", []Usage{
+			{Model: "gpt-4o", PromptTokens: 10},
+			{Model: "gpt-4o", PromptTokens: 20, Estimated: true},
+		}, Usage{Model: "gpt-4o", PromptTokens: 30, Estimated: true}},
+		// an empty usage does not hide the model
+		{"first empty", []Usage{{}, {Model: "gpt-4o", PromptTokens: 10}}, Usage{Model: "gpt-4o", PromptTokens: 10}},
+	}
+	for _, test := range tests {
+		var sum Usage
+		for _, usage := range test.usages {
+			sum = sum.Add(usage)
+		}
+		if sum != test.sum {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.sum, sum)
+		}
+	}
+}
+
+// usageProvider reports the usage of every successful request.
+type usageProvider struct {
+	flakyProvider
+	responses []string
+}
+
+func (u *usageProvider) Request(ctx context.Context, system, prompt string) (string, error) {
+	if _, err := u.flakyProvider.Request(ctx, system, prompt); err != nil {
+		return "", err
+	}
+	response := u.responses[0]
+	u.responses = u.responses[1:]
+	ReportUsage(ctx, Usage{Model: "gpt-4o", PromptTokens: 100, CompletionTokens: 10})
+	return response, nil
+}
+
+func TestResultUsage(t *testing.T) {
+	llm := &usageProvider{responses: []string{
+		"THOUGHT: I look.\nACTION: look north",
+		"I have no idea.",
+		"ANSWER: 1 coin",
+	}}
+	// the first request is retried
+	llm.errs = []error{&ProviderError{StatusCode: http.StatusTooManyRequests}}
+	r, err := NewReact(NewRetryProvider(llm).WithBackoff(time.Millisecond, time.Millisecond),
+		lookCommands(new([]string)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.WithPricing(Pricing{"gpt-4o": {Prompt: 2.5, Completion: 10}})
+
+	result, err := r.QuestionResult(context.Background(), "How many coins are there?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2 steps and a repair of the response without answer
+	if result.LLMCalls != 3 || llm.requests != 4 {
+		t.Errorf("expected 3 LLM calls in 4 requests, got %d in %d", result.LLMCalls, llm.requests)
+	}
+	if result.PromptTokens != 300 || result.CompletionTokens != 30 || result.TotalTokens() != 330 {
+		t.Errorf("unexpected tokens %d + %d", result.PromptTokens, result.CompletionTokens)
+	}
+	want := Usage{Model: "gpt-4o", PromptTokens: 300, CompletionTokens: 30, Cost: 0.00105}
+	usage := result.Usage[PurposeReasoning]
+	if usage.Model != want.Model || usage.TotalTokens() != want.TotalTokens() ||
+		math.Abs(usage.Cost-want.Cost) > 1e-12 || math.Abs(result.Cost-want.Cost) > 1e-12 {
+		t.Errorf("expected %+v, got %+v with cost %v", want, usage, result.Cost)
+	}
+}